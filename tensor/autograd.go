@@ -0,0 +1,431 @@
+package tensor
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Variable wraps a *Dense with optional reverse-mode gradient tracking.
+// A Variable behaves exactly like the *Dense it wraps (its methods are
+// promoted), but when RequiresGrad is set, any StdEng op it takes part in
+// also appends a node to the current goroutine's tape, and grad
+// accumulates lazily into .grad as Backward walks that tape.
+type Variable struct {
+	*Dense
+	RequiresGrad bool
+	grad         *Dense
+}
+
+// NewVariable wraps d as a Variable. requiresGrad controls whether ops
+// involving it are recorded on the tape.
+func NewVariable(d *Dense, requiresGrad bool) *Variable {
+	return &Variable{Dense: d, RequiresGrad: requiresGrad}
+}
+
+// Grad returns the accumulated gradient, or nil if Backward has not yet
+// been called (or this Variable never required grad).
+func (v *Variable) Grad() *Dense { return v.grad }
+
+// ZeroGrad clears the accumulated gradient, as is typically done between
+// training steps before the next Backward call.
+func (v *Variable) ZeroGrad() { v.grad = nil }
+
+// Detach returns a new Variable sharing the same underlying data but
+// with RequiresGrad false and no link to the tape: ops on it are never
+// recorded, and gradients never flow through it.
+func (v *Variable) Detach() *Variable {
+	return &Variable{Dense: v.Dense}
+}
+
+// tapeNode records one differentiable op: the Variables it read, the
+// Variable it produced, and a closure computing the local
+// vector-Jacobian product given the gradient of the output.
+type tapeNode struct {
+	op       string
+	inputs   []*Variable
+	output   *Variable
+	backward func(gradOut *Dense) []*Dense
+}
+
+// tape is a per-goroutine, append-only log of tapeNodes in the order
+// they were executed. Because each node can only reference Variables
+// produced by earlier nodes (or tape-external leaves), walking the slice
+// in reverse always visits nodes in a valid reverse-topological order -
+// no separate graph traversal is needed.
+type tape struct {
+	mu        sync.Mutex
+	nodes     []*tapeNode
+	recording bool
+}
+
+var tapes sync.Map // map[uint64]*tape, one entry per goroutine that has touched autograd
+
+// goroutineID extracts the calling goroutine's id from its stack trace.
+// Go deliberately exposes no public goroutine-local storage; parsing
+// "goroutine N [...]" out of runtime.Stack is the standard workaround
+// when per-goroutine state (like this tape) is required without
+// threading a context through every call.
+//
+// Ids are reused once a goroutine exits, so a tape left in the tapes map
+// by a goroutine that has since exited can be silently handed to an
+// unrelated later goroutine that happens to be assigned the same id.
+// ReleaseTape exists to let long-lived pools avoid this by explicitly
+// dropping a goroutine's entry before it exits.
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	buf = buf[:bytes.IndexByte(buf, ' ')]
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
+
+// currentTape returns the calling goroutine's tape, creating it (with
+// recording enabled) on first use.
+func currentTape() *tape {
+	gid := goroutineID()
+	if t, ok := tapes.Load(gid); ok {
+		return t.(*tape)
+	}
+	t := &tape{recording: true}
+	actual, _ := tapes.LoadOrStore(gid, t)
+	return actual.(*tape)
+}
+
+// ResetTape discards the calling goroutine's recorded tape nodes,
+// restarting with an empty (but still recording) tape. Backward already
+// does this once it's done consuming the nodes it walked; call ResetTape
+// directly when a forward pass's nodes need to be discarded without ever
+// calling Backward on them (e.g. an eval-mode forward run by mistake
+// without wrapping it in NoGrad), so the tape doesn't grow unboundedly
+// across later training steps.
+func ResetTape() {
+	t := currentTape()
+	t.mu.Lock()
+	t.nodes = nil
+	t.mu.Unlock()
+}
+
+// ReleaseTape removes the calling goroutine's tape from the shared
+// tapes map entirely. Call this when a goroutine that has used autograd
+// is about to exit, particularly in a worker pool where goroutine ids
+// get reused - otherwise its slot lingers and can later be mistaken for
+// a new goroutine's tape if the runtime reassigns the same id.
+func ReleaseTape() {
+	tapes.Delete(goroutineID())
+}
+
+// NoGrad runs fn with tape recording disabled for the calling goroutine,
+// then restores the previous recording state. Ops performed inside fn
+// return plain, untracked results even if their operands are Variables
+// with RequiresGrad set.
+func NoGrad(fn func()) {
+	t := currentTape()
+	prev := t.recording
+	t.recording = false
+	defer func() { t.recording = prev }()
+	fn()
+}
+
+// recordTapeNode appends a node to the calling goroutine's tape, unless
+// recording is currently disabled (e.g. inside NoGrad).
+func recordTapeNode(op string, inputs []*Variable, output *Variable, backward func(gradOut *Dense) []*Dense) {
+	t := currentTape()
+	if !t.recording {
+		return
+	}
+	t.mu.Lock()
+	t.nodes = append(t.nodes, &tapeNode{op: op, inputs: inputs, output: output, backward: backward})
+	t.mu.Unlock()
+}
+
+// trackedBinary reports whether either operand of a binary op is a
+// Variable requiring grad, with the calling goroutine's tape currently
+// recording. It's the gate StdEng ops use to decide whether to take the
+// plain path or the tape-recording path.
+func trackedBinary(av, bv *Variable) bool {
+	return currentTape().recording && ((av != nil && av.RequiresGrad) || (bv != nil && bv.RequiresGrad))
+}
+
+// Backward computes gradients for every Variable that fed into v,
+// seeding v's own gradient with a tensor of ones and propagating it
+// backward through the calling goroutine's tape. Results accumulate into
+// each Variable's .grad, matching the += semantics needed when a
+// Variable is reused by more than one op. Once the tape has been walked,
+// Backward clears it (see ResetTape) so the next training step's ops
+// start recording onto a fresh, empty tape instead of growing the same
+// one without bound.
+func (v *Variable) Backward() error {
+	if !v.RequiresGrad {
+		return errors.New("cannot call Backward() on a Variable that does not require grad")
+	}
+
+	seed, err := onesLike(v.Dense)
+	if err != nil {
+		return errors.Wrap(err, "failed to seed Backward with a ones tensor")
+	}
+	v.grad = seed
+
+	t := currentTape()
+	for i := len(t.nodes) - 1; i >= 0; i-- {
+		node := t.nodes[i]
+		if node.output.grad == nil {
+			// output never used (directly or transitively) by v; skip.
+			continue
+		}
+		grads := node.backward(node.output.grad)
+		for j, in := range node.inputs {
+			if in == nil || j >= len(grads) {
+				continue
+			}
+			accumulateGrad(in, grads[j])
+		}
+	}
+	ResetTape()
+	return nil
+}
+
+// accumulateGrad adds g into v.grad (allocating it on first use), which
+// is what makes reusing a Variable across multiple ops sum their
+// gradient contributions instead of clobbering one another.
+func accumulateGrad(v *Variable, g *Dense) {
+	if v == nil || !v.RequiresGrad || g == nil {
+		return
+	}
+	if v.grad == nil {
+		v.grad = g
+		return
+	}
+	sum, err := StdEng{}.Add(v.grad, g, UseUnsafe())
+	if err != nil {
+		return
+	}
+	v.grad = sum.(*Dense)
+}
+
+// reduceGradTo sums gradOut down to shape, undoing any broadcasting that
+// happened in the forward pass: leading axes that shape doesn't have,
+// and axes where shape is 1 but gradOut is larger, are summed out.
+func reduceGradTo(gradOut *Dense, shape Shape) *Dense {
+	if shape == nil || gradOut.Shape().Eq(shape) {
+		return gradOut
+	}
+
+	diff := len(gradOut.Shape()) - len(shape)
+	axes := make([]int, 0, diff+len(shape))
+	for i := 0; i < diff; i++ {
+		axes = append(axes, i)
+	}
+	for i, dim := range shape {
+		if dim == 1 && gradOut.Shape()[i+diff] != 1 {
+			axes = append(axes, i+diff)
+		}
+	}
+
+	summed, err := gradOut.Sum(axes...)
+	if err != nil {
+		return gradOut
+	}
+	if err := summed.Reshape(shape...); err != nil {
+		return summed
+	}
+	return summed
+}
+
+// recordBinaryVariable is the Variable-aware plumbing shared by
+// addVariable/subVariable/mulVariable/divVariable: it runs fwd (the
+// plain, *Dense-level op) and, only if either operand requires grad and
+// the tape is currently recording, wraps the result as a new Variable
+// and appends a tapeNode computing the op's local gradient via backward.
+func recordBinaryVariable(op string, av, bv *Variable, aIsVar, bIsVar bool, fwd func() (Tensor, error), backward func(gradOut *Dense) []*Dense) (Tensor, error) {
+	out, err := fwd()
+	if err != nil {
+		return nil, err
+	}
+
+	if !trackedBinary(av, bv) {
+		return out, nil
+	}
+
+	outDense, err := getDense(out)
+	if err != nil {
+		return nil, err
+	}
+
+	result := NewVariable(outDense, true)
+	recordTapeNode(op, []*Variable{av, bv}, result, backward)
+	return result, nil
+}
+
+// addVariable is StdEng.Add's Variable-aware path: it unwraps any
+// Variable operand to its underlying *Dense, delegates to the plain
+// Add, and records a tape node computing Add's (trivial, pass-through)
+// local gradient.
+func (e StdEng) addVariable(av *Variable, aIsVar bool, bv *Variable, bIsVar bool, a, b Tensor, opts ...FuncOpt) (Tensor, error) {
+	aVal, bVal := a, b
+	if aIsVar {
+		aVal = av.Dense
+	}
+	if bIsVar {
+		bVal = bv.Dense
+	}
+
+	var aShape, bShape Shape
+	if aIsVar {
+		aShape = av.Shape()
+	}
+	if bIsVar {
+		bShape = bv.Shape()
+	}
+
+	return recordBinaryVariable("Add", av, bv, aIsVar, bIsVar,
+		func() (Tensor, error) { return e.Add(aVal, bVal, opts...) },
+		func(gradOut *Dense) []*Dense {
+			return []*Dense{reduceGradTo(gradOut, aShape), reduceGradTo(gradOut, bShape)}
+		})
+}
+
+// subVariable is StdEng.Sub's Variable-aware path. d(a-b)/da is the
+// identity, and d(a-b)/db negates the incoming gradient.
+func (e StdEng) subVariable(av *Variable, aIsVar bool, bv *Variable, bIsVar bool, a, b Tensor, opts ...FuncOpt) (Tensor, error) {
+	aVal, bVal := a, b
+	if aIsVar {
+		aVal = av.Dense
+	}
+	if bIsVar {
+		bVal = bv.Dense
+	}
+
+	var aShape, bShape Shape
+	if aIsVar {
+		aShape = av.Shape()
+	}
+	if bIsVar {
+		bShape = bv.Shape()
+	}
+
+	return recordBinaryVariable("Sub", av, bv, aIsVar, bIsVar,
+		func() (Tensor, error) { return e.Sub(aVal, bVal, opts...) },
+		func(gradOut *Dense) []*Dense {
+			gradB, err := negated(gradOut)
+			if err != nil {
+				return []*Dense{reduceGradTo(gradOut, aShape), nil}
+			}
+			return []*Dense{reduceGradTo(gradOut, aShape), reduceGradTo(gradB, bShape)}
+		})
+}
+
+// mulVariable is StdEng.Mul's Variable-aware path. By the product rule,
+// d(a*b)/da is b and d(a*b)/db is a, so the incoming gradient is scaled
+// by the other operand's forward value.
+func (e StdEng) mulVariable(av *Variable, aIsVar bool, bv *Variable, bIsVar bool, a, b Tensor, opts ...FuncOpt) (Tensor, error) {
+	aVal, bVal := a, b
+	if aIsVar {
+		aVal = av.Dense
+	}
+	if bIsVar {
+		bVal = bv.Dense
+	}
+
+	var aShape, bShape Shape
+	if aIsVar {
+		aShape = av.Shape()
+	}
+	if bIsVar {
+		bShape = bv.Shape()
+	}
+
+	return recordBinaryVariable("Mul", av, bv, aIsVar, bIsVar,
+		func() (Tensor, error) { return e.Mul(aVal, bVal, opts...) },
+		func(gradOut *Dense) []*Dense {
+			gradA, errA := StdEng{}.Mul(gradOut, bVal, WithBroadcast())
+			gradB, errB := StdEng{}.Mul(gradOut, aVal, WithBroadcast())
+			if errA != nil || errB != nil {
+				return nil
+			}
+			gradADense, errA := getDense(gradA)
+			gradBDense, errB := getDense(gradB)
+			if errA != nil || errB != nil {
+				return nil
+			}
+			return []*Dense{reduceGradTo(gradADense, aShape), reduceGradTo(gradBDense, bShape)}
+		})
+}
+
+// divVariable is StdEng.Div's Variable-aware path. By the quotient rule,
+// d(a/b)/da is 1/b and d(a/b)/db is -a/b^2.
+func (e StdEng) divVariable(av *Variable, aIsVar bool, bv *Variable, bIsVar bool, a, b Tensor, opts ...FuncOpt) (Tensor, error) {
+	aVal, bVal := a, b
+	if aIsVar {
+		aVal = av.Dense
+	}
+	if bIsVar {
+		bVal = bv.Dense
+	}
+
+	var aShape, bShape Shape
+	if aIsVar {
+		aShape = av.Shape()
+	}
+	if bIsVar {
+		bShape = bv.Shape()
+	}
+
+	return recordBinaryVariable("Div", av, bv, aIsVar, bIsVar,
+		func() (Tensor, error) { return e.Div(aVal, bVal, opts...) },
+		func(gradOut *Dense) []*Dense {
+			gradA, err := StdEng{}.Div(gradOut, bVal, WithBroadcast())
+			if err != nil {
+				return nil
+			}
+			bSq, err := StdEng{}.Mul(bVal, bVal, WithBroadcast())
+			if err != nil {
+				return nil
+			}
+			gradBNum, err := StdEng{}.Mul(gradOut, aVal, WithBroadcast())
+			if err != nil {
+				return nil
+			}
+			gradBRaw, err := StdEng{}.Div(gradBNum, bSq, WithBroadcast())
+			if err != nil {
+				return nil
+			}
+			gradADense, errA := getDense(gradA)
+			gradBRawDense, errB := getDense(gradBRaw)
+			if errA != nil || errB != nil {
+				return nil
+			}
+			gradBDense, err := negated(gradBRawDense)
+			if err != nil {
+				return nil
+			}
+			return []*Dense{reduceGradTo(gradADense, aShape), reduceGradTo(gradBDense, bShape)}
+		})
+}
+
+// negated returns a new *Dense holding -d, used by Sub/Div's backward
+// closures to flip the sign of a gradient contribution.
+func negated(d *Dense) (*Dense, error) {
+	out, err := StdEng{}.MulScalar(d, -1, true)
+	if err != nil {
+		return nil, err
+	}
+	return getDense(out)
+}
+
+// onesLike allocates a new Dense of d's shape and dtype, filled with 1.
+func onesLike(d *Dense) (*Dense, error) {
+	one, err := convertToDtype(1, d.Dtype().Type, d.Dtype())
+	if err != nil {
+		return nil, err
+	}
+	o := New(Of(d.Dtype()), WithShape(d.Shape()...))
+	if err := o.Memset(one); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
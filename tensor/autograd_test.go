@@ -0,0 +1,131 @@
+package tensor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestVariableAddBackward exercises the tape path that predates this file:
+// Add was the only op wired into Backward, so this is also the first test
+// of that wiring.
+func TestVariableAddBackward(t *testing.T) {
+	a := NewVariable(New(Of(Float64), WithShape(2), WithBacking([]float64{1, 2})), true)
+	b := NewVariable(New(Of(Float64), WithShape(2), WithBacking([]float64{3, 4})), true)
+
+	out, err := StdEng{}.Add(a, b)
+	if err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	outVar := out.(*Variable)
+	assert.Equal(t, []float64{4, 6}, outVar.Data())
+
+	if err := outVar.Backward(); err != nil {
+		t.Fatalf("Backward returned error: %v", err)
+	}
+	assert.Equal(t, []float64{1, 1}, a.Grad().Data())
+	assert.Equal(t, []float64{1, 1}, b.Grad().Data())
+}
+
+// TestVariableSubBackward checks Sub's backward: d/da is the identity,
+// d/db negates the incoming gradient.
+func TestVariableSubBackward(t *testing.T) {
+	a := NewVariable(New(Of(Float64), WithShape(2), WithBacking([]float64{5, 7})), true)
+	b := NewVariable(New(Of(Float64), WithShape(2), WithBacking([]float64{3, 4})), true)
+
+	out, err := StdEng{}.Sub(a, b)
+	if err != nil {
+		t.Fatalf("Sub returned error: %v", err)
+	}
+	outVar := out.(*Variable)
+	assert.Equal(t, []float64{2, 3}, outVar.Data())
+
+	if err := outVar.Backward(); err != nil {
+		t.Fatalf("Backward returned error: %v", err)
+	}
+	assert.Equal(t, []float64{1, 1}, a.Grad().Data())
+	assert.Equal(t, []float64{-1, -1}, b.Grad().Data())
+}
+
+// TestVariableMulBackward checks Mul's backward follows the product rule:
+// d/da is b's forward value, d/db is a's.
+func TestVariableMulBackward(t *testing.T) {
+	a := NewVariable(New(Of(Float64), WithShape(2), WithBacking([]float64{2, 3})), true)
+	b := NewVariable(New(Of(Float64), WithShape(2), WithBacking([]float64{4, 5})), true)
+
+	out, err := StdEng{}.Mul(a, b)
+	if err != nil {
+		t.Fatalf("Mul returned error: %v", err)
+	}
+	outVar := out.(*Variable)
+	assert.Equal(t, []float64{8, 15}, outVar.Data())
+
+	if err := outVar.Backward(); err != nil {
+		t.Fatalf("Backward returned error: %v", err)
+	}
+	assert.Equal(t, []float64{4, 5}, a.Grad().Data())
+	assert.Equal(t, []float64{2, 3}, b.Grad().Data())
+}
+
+// TestVariableDivBackward checks Div's backward follows the quotient
+// rule: d/da is 1/b, d/db is -a/b^2.
+func TestVariableDivBackward(t *testing.T) {
+	a := NewVariable(New(Of(Float64), WithShape(2), WithBacking([]float64{6, 9})), true)
+	b := NewVariable(New(Of(Float64), WithShape(2), WithBacking([]float64{2, 3})), true)
+
+	out, err := StdEng{}.Div(a, b)
+	if err != nil {
+		t.Fatalf("Div returned error: %v", err)
+	}
+	outVar := out.(*Variable)
+	assert.Equal(t, []float64{3, 3}, outVar.Data())
+
+	if err := outVar.Backward(); err != nil {
+		t.Fatalf("Backward returned error: %v", err)
+	}
+	assert.Equal(t, []float64{0.5, 1.0 / 3}, a.Grad().Data())
+	assert.Equal(t, []float64{-1.5, -1}, b.Grad().Data())
+}
+
+// TestVariableAccumulatesAcrossUses checks that reusing the same Variable
+// in more than one op sums its gradient contributions instead of
+// clobbering them - the scenario accumulateGrad exists for.
+func TestVariableAccumulatesAcrossUses(t *testing.T) {
+	a := NewVariable(New(Of(Float64), WithShape(2), WithBacking([]float64{1, 2})), true)
+	b := NewVariable(New(Of(Float64), WithShape(2), WithBacking([]float64{3, 4})), true)
+
+	sum, err := StdEng{}.Add(a, b)
+	if err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	out, err := StdEng{}.Add(sum, a)
+	if err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	outVar := out.(*Variable)
+
+	if err := outVar.Backward(); err != nil {
+		t.Fatalf("Backward returned error: %v", err)
+	}
+	assert.Equal(t, []float64{2, 2}, a.Grad().Data())
+	assert.Equal(t, []float64{1, 1}, b.Grad().Data())
+}
+
+// TestBackwardClearsTape checks that Backward discards the nodes it
+// walked, so a second, unrelated forward/backward pass on the same
+// goroutine starts from an empty tape instead of accumulating nodes
+// without bound across training steps.
+func TestBackwardClearsTape(t *testing.T) {
+	a := NewVariable(New(Of(Float64), WithShape(2), WithBacking([]float64{1, 2})), true)
+	b := NewVariable(New(Of(Float64), WithShape(2), WithBacking([]float64{3, 4})), true)
+
+	out, err := StdEng{}.Add(a, b)
+	if err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	if err := out.(*Variable).Backward(); err != nil {
+		t.Fatalf("Backward returned error: %v", err)
+	}
+
+	assert.Empty(t, currentTape().nodes, "Backward should have cleared the tape")
+}
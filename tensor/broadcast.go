@@ -0,0 +1,80 @@
+package tensor
+
+import "github.com/pkg/errors"
+
+// WithBroadcast allows a binary StdEng operation (Add, Sub, Mul, Div, Pow,
+// and the comparison ops) to broadcast its operands' shapes under NumPy
+// rules instead of requiring them to match exactly. Without this option,
+// StdEng keeps its historical strict-shape behaviour.
+func WithBroadcast() FuncOpt {
+	return func(opt *OpOpt) {
+		opt.broadcast = true
+	}
+}
+
+// BroadcastShapes computes the NumPy-style broadcast of two shapes: the
+// shapes are right-aligned, each aligned axis pair must be equal or
+// contain a 1, and missing leading axes are treated as 1. The result
+// shape takes the max of each aligned axis.
+func BroadcastShapes(a, b Shape) (Shape, error) {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+
+	result := make(Shape, n)
+	for i := 0; i < n; i++ {
+		ai, bi := 1, 1
+		if idx := len(a) - n + i; idx >= 0 {
+			ai = a[idx]
+		}
+		if idx := len(b) - n + i; idx >= 0 {
+			bi = b[idx]
+		}
+
+		switch {
+		case ai == bi:
+			result[i] = ai
+		case ai == 1:
+			result[i] = bi
+		case bi == 1:
+			result[i] = ai
+		default:
+			return nil, errors.Errorf(shapeMismatch, a, b)
+		}
+	}
+	return result, nil
+}
+
+// broadcastStrides computes the strides a tensor of shape `orig` and
+// strides `origStrides` would have if viewed as shape `out`: axes that
+// had to be stretched to match `out` (either because they were of length
+// 1, or because `orig` had fewer axes than `out`) get stride 0, so that
+// walking the expanded shape revisits the same underlying elements.
+func broadcastStrides(orig, out Shape, origStrides []int) []int {
+	strides := make([]int, len(out))
+	offset := len(out) - len(orig)
+	for i := range strides {
+		oi := i - offset
+		switch {
+		case oi < 0:
+			strides[i] = 0
+		case orig[oi] == 1 && out[i] != 1:
+			strides[i] = 0
+		default:
+			strides[i] = origStrides[oi]
+		}
+	}
+	return strides
+}
+
+// broadcastIterator returns an Iterator that walks t's data as though it
+// had shape `out`, without copying: axes of length 1 (and any missing
+// leading axes) are given stride 0 so the same elements are repeated as
+// the virtual expanded shape is walked.
+func broadcastIterator(t DenseTensor, out Shape) Iterator {
+	orig := t.Shape()
+	strides := broadcastStrides(orig, out, t.Strides())
+	ap := NewAP(out, strides)
+	return NewFlatIterator(ap)
+}
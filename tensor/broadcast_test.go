@@ -0,0 +1,89 @@
+package tensor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBroadcastShapes checks the NumPy right-align-and-match-or-1 rule
+// directly, independent of any engine dispatch.
+func TestBroadcastShapes(t *testing.T) {
+	tests := []struct {
+		a, b Shape
+		want Shape
+	}{
+		{Shape{3, 4}, Shape{4}, Shape{3, 4}},
+		{Shape{3, 1}, Shape{3, 4}, Shape{3, 4}},
+		{Shape{1}, Shape{3, 4}, Shape{3, 4}},
+		{Shape{5, 1, 4}, Shape{1, 3, 4}, Shape{5, 3, 4}},
+	}
+	for _, tc := range tests {
+		got, err := BroadcastShapes(tc.a, tc.b)
+		if err != nil {
+			t.Errorf("BroadcastShapes(%v, %v) returned error: %v", tc.a, tc.b, err)
+			continue
+		}
+		assert.True(t, got.Eq(tc.want), "BroadcastShapes(%v, %v) = %v, want %v", tc.a, tc.b, got, tc.want)
+	}
+}
+
+// TestAddBroadcastRightOperand covers the case the original
+// implementation got right: b is smaller than a and needs broadcasting.
+func TestAddBroadcastRightOperand(t *testing.T) {
+	x := New(Of(Float64), WithShape(3, 2), WithBacking([]float64{1, 2, 3, 4, 5, 6}))
+	bias := New(Of(Float64), WithShape(2), WithBacking([]float64{10, 100}))
+
+	got, err := StdEng{}.Add(x, bias, WithBroadcast())
+	if err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	gotDense := got.(*Dense)
+	assert.True(t, gotDense.Shape().Eq(Shape{3, 2}))
+	assert.Equal(t, []float64{11, 102, 13, 104, 15, 106}, gotDense.Data())
+}
+
+// TestAddBroadcastLeftOperand is the case that silently corrupted data
+// before this fix: a (the operand whose shape/clone the safe path used
+// to reuse) is the smaller, broadcasting operand.
+func TestAddBroadcastLeftOperand(t *testing.T) {
+	bias := New(Of(Float64), WithShape(3, 1), WithBacking([]float64{10, 20, 30}))
+	x := New(Of(Float64), WithShape(3, 2), WithBacking([]float64{1, 2, 3, 4, 5, 6}))
+
+	got, err := StdEng{}.Add(bias, x, WithBroadcast())
+	if err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	gotDense := got.(*Dense)
+	assert.True(t, gotDense.Shape().Eq(Shape{3, 2}), "result shape %v should be the broadcast shape %v, not a's original shape", gotDense.Shape(), Shape{3, 2})
+	assert.Equal(t, []float64{11, 12, 23, 24, 35, 36}, gotDense.Data())
+}
+
+// TestAddBroadcastLeftOperandReuse exercises the toReuse path when a
+// needs broadcasting: the pre-allocated reuse tensor is already
+// newShape-sized, so priming it from a's too-small buffer must not be a
+// raw same-size byte copy.
+func TestAddBroadcastLeftOperandReuse(t *testing.T) {
+	bias := New(Of(Float64), WithShape(3, 1), WithBacking([]float64{10, 20, 30}))
+	x := New(Of(Float64), WithShape(3, 2), WithBacking([]float64{1, 2, 3, 4, 5, 6}))
+	reuse := New(Of(Float64), WithShape(3, 2), WithBacking([]float64{-1, -1, -1, -1, -1, -1}))
+
+	got, err := StdEng{}.Add(bias, x, WithBroadcast(), WithReuse(reuse))
+	if err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	gotDense := got.(*Dense)
+	assert.True(t, gotDense.Shape().Eq(Shape{3, 2}))
+	assert.Equal(t, []float64{11, 12, 23, 24, 35, 36}, gotDense.Data())
+}
+
+// TestAddBroadcastLeftOperandUnsafe asserts that growing a in place is
+// rejected with an error rather than silently returning a's original,
+// too-small tensor.
+func TestAddBroadcastLeftOperandUnsafe(t *testing.T) {
+	bias := New(Of(Float64), WithShape(3, 1), WithBacking([]float64{10, 20, 30}))
+	x := New(Of(Float64), WithShape(3, 2), WithBacking([]float64{1, 2, 3, 4, 5, 6}))
+
+	_, err := StdEng{}.Add(bias, x, WithBroadcast(), UseUnsafe())
+	assert.Error(t, err, "growing a's storage in an unsafe op should error, not silently return a's original tensor")
+}
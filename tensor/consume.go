@@ -0,0 +1,144 @@
+package tensor
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Releaser lets an Engine reclaim a header's backing storage once it's
+// no longer needed, instead of waiting for the garbage collector to find
+// it. The default CPU engine implements this by pooling the backing
+// slice; a CUDA/OpenCL engine can implement it to free the corresponding
+// device buffer immediately.
+type Releaser interface {
+	Release(hdr *header)
+}
+
+// WithConsume marks a as no longer needed by the caller once the op
+// completes. Combined with an unsafe op (the default; i.e. without
+// WithSafe()), this lets the engine return a's backing storage to its
+// pool (or free device memory) right away rather than waiting on the
+// GC. After the op runs, a's header is zeroed out, so any further use of
+// a panics loudly instead of silently reading freed or recycled memory.
+//
+// This mirrors the "delete tensor after applying operator" pattern
+// common in Python tensor library bindings, and is most useful in
+// training loops that would otherwise accumulate a lot of short-lived
+// intermediates.
+func WithConsume(a Tensor) FuncOpt {
+	return func(opt *OpOpt) {
+		opt.consume = a
+	}
+}
+
+// releaseConsumed hands consumed's backing storage to e's Releaser (or
+// the default CPU pool if e doesn't implement Releaser), then zeroes out
+// consumed's header and access pattern so it can never be read again.
+func releaseConsumed(e StdEng, consumed Tensor) {
+	hdrer, ok := consumed.(headerer)
+	if !ok {
+		return
+	}
+	hdr := hdrer.hdr()
+
+	if r, ok := e.E.(Releaser); ok {
+		r.Release(hdr)
+	} else {
+		cpuPool.Release(hdr)
+	}
+
+	*hdr = header{}
+	if d, ok := consumed.(*Dense); ok {
+		d.AP = AP{}
+	}
+}
+
+// poolKey buckets pooled slices by element kind and a power-of-two
+// capacity, the same tradeoff sync.Pool-based buffer pools commonly
+// make: a handful of size classes wastes some memory to headroom but
+// keeps hit rate high for the common case of same-shaped tensors being
+// allocated and released in a loop.
+type poolKey struct {
+	kind     reflect.Kind
+	capacity int
+}
+
+// cpuBufferPool is the default Releaser used whenever an Engine doesn't
+// implement its own: it returns a consumed header's backing slice to a
+// sync.Pool keyed by dtype and capacity bucket, and Get hands one back
+// out to denseFromFuncOpts-style allocation paths before falling back to
+// make().
+type cpuBufferPool struct {
+	pools sync.Map // map[poolKey]*sync.Pool
+}
+
+var cpuPool cpuBufferPool
+
+func bucket(n int) int {
+	b := 1
+	for b < n {
+		b <<= 1
+	}
+	return b
+}
+
+func (p *cpuBufferPool) poolFor(kind reflect.Kind, capacity int) *sync.Pool {
+	key := poolKey{kind: kind, capacity: bucket(capacity)}
+	if v, ok := p.pools.Load(key); ok {
+		return v.(*sync.Pool)
+	}
+	actual, _ := p.pools.LoadOrStore(key, new(sync.Pool))
+	return actual.(*sync.Pool)
+}
+
+// Release returns hdr's backing slice to the pool bucketed by its
+// element kind and capacity.
+func (p *cpuBufferPool) Release(hdr *header) {
+	if hdr == nil || hdr.raw == nil {
+		return
+	}
+	p.poolFor(hdr.t.Kind(), hdr.l).Put(hdr.raw)
+}
+
+// Get returns a pooled backing slice for capacity elements of the given
+// kind, or nil if the pool is empty (the caller should then make() one).
+func (p *cpuBufferPool) Get(kind reflect.Kind, capacity int) interface{} {
+	return p.poolFor(kind, capacity).Get()
+}
+
+// pooledDense allocates a fresh *Dense of the given Dtype and Shape,
+// preferring a buffer already sitting in cpuPool over a new make() -
+// this is what makes a Release actually get reused by a later
+// allocation, instead of every released buffer just sitting idle until
+// the next one happens to come from the same bucket by chance.
+//
+// A bucketed buffer's length is rounded up to the next power of two, so
+// it may be longer than the n elements actually needed; it's resliced
+// down to exactly n before use.
+func pooledDense(dt Dtype, shape Shape) *Dense {
+	n := shape.TotalSize()
+	if raw := cpuPool.Get(dt.Kind(), n); raw != nil {
+		rv := reflect.ValueOf(raw)
+		if rv.Len() >= n {
+			backing := rv.Slice(0, n).Interface()
+			return New(Of(dt), WithShape(shape...), WithBacking(backing)).(*Dense)
+		}
+	}
+	return New(Of(dt), WithShape(shape...)).(*Dense)
+}
+
+// pooledClone behaves like a.Clone(), copying a's data into a freshly
+// allocated Dense of the same Dtype and Shape, but when a is a *Dense it
+// goes through pooledDense for that allocation - the same pool reuse
+// pooledDense already gives the aNeedsGrow broadcast-grow path, extended
+// to the same-shape safe-op path, which is the far more common case in a
+// training loop that's already using WithConsume to release buffers.
+func pooledClone(a Tensor) Tensor {
+	d, ok := a.(*Dense)
+	if !ok {
+		return a.Clone()
+	}
+	dst := pooledDense(d.Dtype(), d.Shape())
+	reflect.Copy(reflect.ValueOf(dst.Data()), reflect.ValueOf(d.Data()))
+	return dst
+}
@@ -0,0 +1,80 @@
+package tensor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithConsumeAliasingResultErrors checks the bug this file's fix
+// addresses: consuming the same operand an unsafe op returns as its
+// result must error instead of silently zeroing out that result.
+func TestWithConsumeAliasingResultErrors(t *testing.T) {
+	a := New(Of(Float64), WithShape(2), WithBacking([]float64{1, 2}))
+	b := New(Of(Float64), WithShape(2), WithBacking([]float64{3, 4}))
+
+	_, err := StdEng{}.Add(a, b, UseUnsafe(), WithConsume(a))
+	assert.Error(t, err, "consuming the operand an unsafe op returns should error, not corrupt the result")
+}
+
+// TestWithConsumeReleasesOperand checks the legitimate case: consuming an
+// operand that is not the result (b, here - a is overwritten in place
+// and returned) actually releases it.
+func TestWithConsumeReleasesOperand(t *testing.T) {
+	a := New(Of(Float64), WithShape(2), WithBacking([]float64{1, 2}))
+	b := New(Of(Float64), WithShape(2), WithBacking([]float64{3, 4}))
+
+	got, err := StdEng{}.Add(a, b, UseUnsafe(), WithConsume(b))
+	if err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	assert.Equal(t, []float64{4, 6}, got.(*Dense).Data())
+
+	bh := b.(headerer).hdr()
+	assert.Nil(t, bh.raw, "b's backing storage should have been released and zeroed")
+}
+
+// TestCpuBufferPoolRoundTrip checks Release/Get directly: a released
+// buffer of a given kind and capacity bucket should come back out of a
+// later Get for that same kind/capacity.
+func TestCpuBufferPoolRoundTrip(t *testing.T) {
+	var pool cpuBufferPool
+	pool.Release(&header{t: Float64, l: 4, raw: []float64{1, 2, 3, 4}})
+
+	got := pool.Get(reflect.Float64, 4)
+	assert.Equal(t, []float64{1, 2, 3, 4}, got)
+}
+
+// TestPooledDenseReusesReleasedBuffer checks that pooledDense - the
+// allocation path StdEng's ops use for a fresh broadcast-grown
+// destination - actually draws from cpuPool instead of always calling
+// New from scratch.
+func TestPooledDenseReusesReleasedBuffer(t *testing.T) {
+	cpuPool.Release(&header{t: Float64, l: 3, raw: []float64{9, 9, 9}})
+
+	d := pooledDense(Float64, Shape{3})
+	assert.Equal(t, []float64{9, 9, 9}, d.Data())
+}
+
+// TestSafeAddReusesReleasedBuffer checks that the common, non-broadcasting
+// safe-op path in binaryOp - Add without WithUnsafe, same-shaped operands -
+// actually draws its result buffer from cpuPool via pooledClone, not just
+// the aNeedsGrow broadcast-grow path pooledDense was already wired into.
+func TestSafeAddReusesReleasedBuffer(t *testing.T) {
+	cpuPool.Release(&header{t: Float64, l: 2, raw: []float64{9, 9}})
+
+	a := New(Of(Float64), WithShape(2), WithBacking([]float64{1, 2}))
+	b := New(Of(Float64), WithShape(2), WithBacking([]float64{3, 4}))
+
+	got, err := StdEng{}.Add(a, b)
+	if err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	assert.Equal(t, []float64{4, 6}, got.(*Dense).Data())
+
+	// The buffer released above is now the only float64/2 entry cpuPool
+	// has ever held; a nil Get confirms pooledClone took it rather than
+	// Add falling back to a fresh allocation and leaving it unclaimed.
+	assert.Nil(t, cpuPool.Get(reflect.Float64, 2), "Add's safe path should have drawn the released buffer out of cpuPool")
+}
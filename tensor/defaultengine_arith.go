@@ -1,8 +1,12 @@
 package tensor
 
-import "github.com/pkg/errors"
+import (
+	"reflect"
 
-func prepBinaryTensor(a, b Tensor, opts ...FuncOpt) (reuse *Dense, safe, toReuse, incr bool, err error) {
+	"github.com/pkg/errors"
+)
+
+func prepBinaryTensor(a, b Tensor, opts ...FuncOpt) (reuse *Dense, safe, toReuse, incr bool, newShape Shape, promoted Dtype, err error) {
 	// check if the tensors are accessible
 	if !a.IsNativelyAccessible() {
 		err = errors.Errorf(inaccessibleData, a)
@@ -21,16 +25,28 @@ func prepBinaryTensor(a, b Tensor, opts ...FuncOpt) (reuse *Dense, safe, toReuse
 		return
 	}
 
-	if at.Kind() != bt.Kind() {
+	fo := ParseFuncOpts(opts...)
+	if at.Kind() == bt.Kind() {
+		promoted = at
+	} else if fo.StrictTypes() {
 		err = errors.Errorf(typeMismatch, at, bt)
 		return
+	} else if promoted, err = PromoteTypes(at, bt); err != nil {
+		return
 	}
 
-	if !a.Shape().Eq(b.Shape()) {
+	if a.Shape().Eq(b.Shape()) {
+		newShape = a.Shape()
+	} else if fo.Broadcast() {
+		if newShape, err = BroadcastShapes(a.Shape(), b.Shape()); err != nil {
+			return
+		}
+	} else {
 		err = errors.Errorf(shapeMismatch, b.Shape(), a.Shape())
 		return
 	}
-	return denseFromFuncOpts(a.Shape(), at, opts...)
+	reuse, safe, toReuse, incr, err = denseFromFuncOpts(newShape, promoted, opts...)
+	return
 }
 
 func prepUnaryTensor(a Tensor, opts ...FuncOpt) (reuse *Dense, safe, toReuse, incr bool, err error) {
@@ -70,20 +86,41 @@ func denseFromFuncOpts(expShape Shape, expType Dtype, opts ...FuncOpt) (reuse *D
 	return
 }
 
-// Add performs a + b. The FuncOpts determine what kind of operation it is.
+// binaryOpFuncs bundles the four StdEng entry points (plain, iterator,
+// incr, iterator+incr) that a dense-dense (or dense-sparse) binary
+// arithmetic op dispatches to - the same grouping scalarOp uses for the
+// scalar-tensor ops. It lets Add/Sub/Mul/Div share the
+// prep/broadcast/promote/dispatch plumbing below instead of each
+// hand-rolling its own copy.
 //
-//		a :: DenseTensor, a :: DenseTensor -> DenseTensor
-//			unsafe overwrites a
-//		a :: SparseTensor, b :: DenseTensor -> DenseTensor
-//			unsafe overwrites b
-//		a :: DenseTensor, b :: SparseTensor -> DenseTensor
-//			unsafe overwrites a
-//		a :: SparseTEnsor, b :: SparseTensor -> SparseTensor
-//			unsafe unsupported
-func (e StdEng) Add(a, b Tensor, opts ...FuncOpt) (retVal Tensor, err error) {
+// Pow and the comparison ops (Gt/Lt/Eq/...) are deliberately not among
+// them: both would need e.E.Pow/e.E.GtIter-style kernels on the
+// underlying Engine, which this package doesn't define. binaryOp itself
+// doesn't care what the op does, so wiring either in later is a matter
+// of adding those kernels and a StdEng.Pow/Gt/... entry point shaped
+// exactly like Add's, below - not a change to this broadcasting/promotion
+// plumbing. (Comparison ops have an extra wrinkle Add/Sub/Mul/Div don't:
+// their result is always Bool regardless of the promoted operand dtype,
+// which binaryOp's typ-preserving reuse/incr paths don't currently
+// account for.)
+type binaryOpFuncs struct {
+	name       string
+	fn         func(typ reflect.Type, a, b *header) error
+	fnIter     func(typ reflect.Type, a, b *header, ait, bit Iterator) error
+	fnIncr     func(typ reflect.Type, a, b, incr *header) error
+	fnIterIncr func(typ reflect.Type, a, b, incr *header, ait, bit, iit Iterator) error
+}
+
+// binaryOp implements `a OP b` for a DenseTensor/DenseTensor,
+// DenseTensor/*CS or *CS/DenseTensor pair, with ops supplying the actual
+// arithmetic. See Add's doc comment for the safe/unsafe/reuse/incr
+// contract and the broadcasting caveat around aNeedsGrow.
+func (e StdEng) binaryOp(ops binaryOpFuncs, a, b Tensor, opts ...FuncOpt) (retVal Tensor, err error) {
 	var reuse *Dense
 	var safe, toReuse, incr bool
-	if reuse, safe, toReuse, incr, err = prepBinaryTensor(a, b, opts...); err != nil {
+	var newShape Shape
+	var promoted Dtype
+	if reuse, safe, toReuse, incr, newShape, promoted, err = prepBinaryTensor(a, b, opts...); err != nil {
 		return
 	}
 
@@ -92,21 +129,44 @@ func (e StdEng) Add(a, b Tensor, opts ...FuncOpt) (retVal Tensor, err error) {
 		return
 	}
 
+	if a, err = promoteOperand(a, promoted); err != nil {
+		return
+	}
+	if b, err = promoteOperand(b, promoted); err != nil {
+		return
+	}
+
 	// prep actual data
 	var dataA, dataB, dataReuse *header
 	var ait, bit, iit Iterator
 	var useIter bool
-	typ := a.Dtype().Type
+	typ := promoted.Type
+
+	// aNeedsGrow is true when a's own backing storage is smaller than
+	// newShape (i.e. a is the operand broadcasting grows), in which case
+	// a's buffer can never serve as the destination: writing through a
+	// stride-0 iterator would repeatedly clobber the same few slots
+	// instead of producing newShape's worth of distinct elements.
+	aNeedsGrow := !a.Shape().Eq(newShape)
 
 	switch at := a.(type) {
 	case DenseTensor:
 		switch bt := b.(type) {
 		case DenseTensor:
-			if requiresIterator(at) || requiresIterator(bt) {
+			broadcasting := aNeedsGrow || !bt.Shape().Eq(newShape)
+			if broadcasting || requiresIterator(at) || requiresIterator(bt) {
 				dataA = at.hdr()
 				dataB = bt.hdr()
-				ait = IteratorFromDense(at)
-				bit = IteratorFromDense(bt)
+				if at.Shape().Eq(newShape) {
+					ait = IteratorFromDense(at)
+				} else {
+					ait = broadcastIterator(at, newShape)
+				}
+				if bt.Shape().Eq(newShape) {
+					bit = IteratorFromDense(bt)
+				} else {
+					bit = broadcastIterator(bt, newShape)
+				}
 				if reuse != nil {
 					iit = IteratorFromDense(reuse)
 					dataReuse = reuse.hdr()
@@ -130,7 +190,7 @@ func (e StdEng) Add(a, b Tensor, opts ...FuncOpt) (retVal Tensor, err error) {
 				iit = IteratorFromDense(reuse)
 			}
 		default:
-			err = errors.Errorf(typeNYI, "e.Add", b)
+			err = errors.Errorf(typeNYI, "e."+ops.name, b)
 		}
 	case *CS:
 		switch bt := b.(type) {
@@ -144,119 +204,211 @@ func (e StdEng) Add(a, b Tensor, opts ...FuncOpt) (retVal Tensor, err error) {
 				iit = IteratorFromDense(reuse)
 			}
 		case *CS:
-			err = errors.Errorf(methodNYI, "Add", "CS-CS")
+			err = errors.Errorf(methodNYI, ops.name, "CS-CS")
 		default:
-			err = errors.Errorf(typeNYI, "e.Add", b)
+			err = errors.Errorf(typeNYI, "e."+ops.name, b)
 		}
 	default:
-		err = errors.Errorf(typeNYI, "e.Add", a)
+		err = errors.Errorf(typeNYI, "e."+ops.name, a)
 	}
 
 	if useIter {
 		switch {
 		case incr:
-			err = e.E.AddIterIncr(typ, dataA, dataB, dataReuse, ait, bit, iit)
+			err = ops.fnIterIncr(typ, dataA, dataB, dataReuse, ait, bit, iit)
 			retVal = reuse
 		case toReuse:
-			copyHeader(dataReuse, dataA, typ)
-			err = e.E.AddIter(typ, dataReuse, dataB, ait, bit)
+			if aNeedsGrow {
+				// dataA is a's original, too-small buffer: copyHeader-ing
+				// it straight into dataReuse (sized for newShape) would
+				// be a bogus, mismatched-size raw copy. Zero dataReuse
+				// and let fnIterIncr compute the op from scratch into it
+				// instead, reading both operands through their (possibly
+				// broadcast) iterators.
+				var zero interface{}
+				if zero, err = convertToDtype(0, typ, promoted); err == nil {
+					if err = reuse.Memset(zero); err == nil {
+						iit = IteratorFromDense(reuse)
+						err = ops.fnIterIncr(typ, dataA, dataB, dataReuse, ait, bit, iit)
+					}
+				}
+			} else {
+				copyHeader(dataReuse, dataA, typ)
+				err = ops.fnIter(typ, dataReuse, dataB, ait, bit)
+			}
 			retVal = reuse
 		case !safe:
-			err = e.E.AddIter(typ, dataA, dataB, ait, bit)
+			if aNeedsGrow {
+				err = errors.Errorf("cannot broadcast %v into %v for an unsafe op: the result no longer fits in a's existing storage; use the default safe op, or WithIncr into a pre-allocated %v-shaped destination, instead", a.Shape(), newShape, newShape)
+				return
+			}
+			err = ops.fnIter(typ, dataA, dataB, ait, bit)
 			retVal = a
 		default:
-			ret := a.Clone().(headerer)
-			err = e.E.AddIter(typ, ret.hdr(), dataB, ait, bit)
-			retVal = ret.(Tensor)
+			if aNeedsGrow {
+				// a's buffer can't be grown into, so allocate a fresh
+				// newShape-sized destination (preferring a pooled buffer
+				// over a fresh make(), per pooledDense), zero it, and
+				// compute the op directly into it via fnIterIncr.
+				dst := pooledDense(promoted, newShape)
+				var zero interface{}
+				if zero, err = convertToDtype(0, typ, promoted); err != nil {
+					return
+				}
+				if err = dst.Memset(zero); err != nil {
+					return
+				}
+				dit := IteratorFromDense(dst)
+				err = ops.fnIterIncr(typ, dataA, dataB, dst.hdr(), ait, bit, dit)
+				retVal = dst
+			} else {
+				ret := pooledClone(a).(headerer)
+				err = ops.fnIter(typ, ret.hdr(), dataB, ait, bit)
+				retVal = ret.(Tensor)
+			}
+		}
+		if err == nil {
+			if rerr := consumeIfSafe(e, retVal, !safe, opts...); rerr != nil {
+				err = rerr
+			}
 		}
 		return
 	}
 	switch {
 	case incr:
-		err = e.E.AddIncr(typ, dataA, dataB, dataReuse)
+		err = ops.fnIncr(typ, dataA, dataB, dataReuse)
 		retVal = reuse
 	case toReuse:
 		copyHeader(dataReuse, dataA, typ)
-		err = e.E.Add(typ, dataReuse, dataB)
+		err = ops.fn(typ, dataReuse, dataB)
 		retVal = reuse
 	case !safe:
-		err = e.E.Add(typ, dataA, dataB)
+		err = ops.fn(typ, dataA, dataB)
 		retVal = a
 	default:
-		ret := a.Clone().(headerer)
-		err = e.E.Add(typ, ret.hdr(), dataB)
+		ret := pooledClone(a).(headerer)
+		err = ops.fn(typ, ret.hdr(), dataB)
 		retVal = ret.(Tensor)
 	}
+	if err == nil {
+		if rerr := consumeIfSafe(e, retVal, !safe, opts...); rerr != nil {
+			err = rerr
+		}
+	}
 	return
 }
 
-/*
-
-// Trans performs the translation option of a + b
-func (e StdEng) Trans(a Tensor, b interface{}, opts ...FuncOpt) (retVal Tensor, err error) {
-	var reuse *Dense
-	var safe, toReuse, incr bool
-	if reuse, safe, toReuse, incr, err = prepUnaryTensor(a, opts...); err != nil {
-		return
+// consumeIfSafe releases WithConsume's tensor, if one was given, once op
+// has finished without error. It refuses to do so when the consumed
+// tensor is backed by the very same header as retVal: consuming it would
+// zero out the value the op is about to hand back to the caller, rather
+// than freeing a genuinely unused intermediate.
+func consumeIfSafe(e StdEng, retVal Tensor, unsafe bool, opts ...FuncOpt) error {
+	if retVal == nil || !unsafe {
+		return nil
 	}
+	consume := ParseFuncOpts(opts...).Consume()
+	if consume == nil {
+		return nil
+	}
+	if sameBacking(consume, retVal) {
+		return errors.Errorf("WithConsume(%v) aliases the op's own result; consuming it would zero out the value being returned - drop WithConsume, or pass a different, genuinely unused tensor", consume)
+	}
+	releaseConsumed(e, consume)
+	return nil
+}
 
-	var ait, iit Iterator
-	var dataA, dataReuse *header
-	var useIter bool
-	scalar := scalarToHeader(b)
-	typ := a.Dtype().Type
+// sameBacking reports whether a and b are backed by the very same
+// header, i.e. releasing one would corrupt the other.
+func sameBacking(a, b Tensor) bool {
+	ah, ok := a.(headerer)
+	if !ok {
+		return false
+	}
+	bh, ok := b.(headerer)
+	if !ok {
+		return false
+	}
+	return ah.hdr() == bh.hdr()
+}
 
-	switch at := a.(type) {
-	case DenseTensor:
-		if requiresIterator(at) {
-			ait = IteratorFromDense(at)
-			if reuse != nil {
-				iit = IteratorFromDense(reuse)
-			}
-			useIter = true
-		}
+// Add performs a + b. The FuncOpts determine what kind of operation it is.
+//
+//		a :: DenseTensor, b :: DenseTensor -> DenseTensor
+//			unsafe overwrites a, unless a's Dtype differs from b's: promoteOperand
+//			then substitutes a freshly-converted copy of a, so the unsafe op
+//			overwrites that copy rather than the original a
+//		a :: SparseTensor, b :: DenseTensor -> DenseTensor
+//			unsafe overwrites b
+//		a :: DenseTensor, b :: SparseTensor -> DenseTensor
+//			unsafe overwrites a
+//		a :: SparseTEnsor, b :: SparseTensor -> SparseTensor
+//			unsafe unsupported
+func (e StdEng) Add(a, b Tensor, opts ...FuncOpt) (retVal Tensor, err error) {
+	av, aIsVar := a.(*Variable)
+	bv, bIsVar := b.(*Variable)
+	if aIsVar || bIsVar {
+		return e.addVariable(av, aIsVar, bv, bIsVar, a, b, opts...)
+	}
+	return e.binaryOp(binaryOpFuncs{
+		name:       "Add",
+		fn:         e.E.Add,
+		fnIter:     e.E.AddIter,
+		fnIncr:     e.E.AddIncr,
+		fnIterIncr: e.E.AddIterIncr,
+	}, a, b, opts...)
+}
 
-	case *CS:
-		return nil, errors.Errorf("NYI")
-	default:
-		return nil, errors.Errorf("NYI")
+// Sub performs a - b. The FuncOpts determine what kind of operation it is;
+// see Add's doc comment for the safe/unsafe/reuse/incr contract, which Sub
+// shares exactly.
+func (e StdEng) Sub(a, b Tensor, opts ...FuncOpt) (retVal Tensor, err error) {
+	av, aIsVar := a.(*Variable)
+	bv, bIsVar := b.(*Variable)
+	if aIsVar || bIsVar {
+		return e.subVariable(av, aIsVar, bv, bIsVar, a, b, opts...)
 	}
+	return e.binaryOp(binaryOpFuncs{
+		name:       "Sub",
+		fn:         e.E.Sub,
+		fnIter:     e.E.SubIter,
+		fnIncr:     e.E.SubIncr,
+		fnIterIncr: e.E.SubIterIncr,
+	}, a, b, opts...)
+}
 
-	if useIter {
-		switch {
-		case incr:
-			err = e.E.AddIterIncr(typ, dataA, scalar, dataReuse, ait, nil, iit)
-			retVal = reuse
-		case toReuse:
-			copyHeader(dataReuse, dataA, typ)
-			err = e.E.AddIter(typ, dataReuse, scalar, ait, nil)
-			retVal = reuse
-		case !safe:
-			err = e.E.AddIter(typ, dataA, scalar, ait, nil)
-			retVal = a
-		default:
-			ret := a.Clone().(headerer)
-			err = e.E.AddIter(typ, ret.hdr(), scalar, ait, nil)
-			retVal = ret.(Tensor)
-		}
-		return
+// Mul performs a * b. The FuncOpts determine what kind of operation it is;
+// see Add's doc comment for the safe/unsafe/reuse/incr contract, which Mul
+// shares exactly.
+func (e StdEng) Mul(a, b Tensor, opts ...FuncOpt) (retVal Tensor, err error) {
+	av, aIsVar := a.(*Variable)
+	bv, bIsVar := b.(*Variable)
+	if aIsVar || bIsVar {
+		return e.mulVariable(av, aIsVar, bv, bIsVar, a, b, opts...)
 	}
-	switch {
-	case incr:
-		err = e.E.AddIncr(typ, dataA, scalar, dataReuse)
-		retVal = reuse
-	case toReuse:
-		copyHeader(dataReuse, dataA, typ)
-		err = e.E.Add(typ, dataReuse, scalar)
-		retVal = reuse
-	case !safe:
-		err = e.E.Add(typ, dataA, scalar)
-		retVal = a
-	default:
-		ret := a.Clone().(headerer)
-		err = e.E.Add(typ, ret.hdr(), scalar)
-		retVal = ret.(Tensor)
+	return e.binaryOp(binaryOpFuncs{
+		name:       "Mul",
+		fn:         e.E.Mul,
+		fnIter:     e.E.MulIter,
+		fnIncr:     e.E.MulIncr,
+		fnIterIncr: e.E.MulIterIncr,
+	}, a, b, opts...)
+}
+
+// Div performs a / b. The FuncOpts determine what kind of operation it is;
+// see Add's doc comment for the safe/unsafe/reuse/incr contract, which Div
+// shares exactly.
+func (e StdEng) Div(a, b Tensor, opts ...FuncOpt) (retVal Tensor, err error) {
+	av, aIsVar := a.(*Variable)
+	bv, bIsVar := b.(*Variable)
+	if aIsVar || bIsVar {
+		return e.divVariable(av, aIsVar, bv, bIsVar, a, b, opts...)
 	}
-	return
+	return e.binaryOp(binaryOpFuncs{
+		name:       "Div",
+		fn:         e.E.Div,
+		fnIter:     e.E.DivIter,
+		fnIncr:     e.E.DivIncr,
+		fnIterIncr: e.E.DivIterIncr,
+	}, a, b, opts...)
 }
-*/
@@ -0,0 +1,309 @@
+package tensor
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// npyMagic is the 6-byte magic string that begins every .npy file.
+var npyMagic = []byte("\x93NUMPY")
+
+// npyHeaderRe picks apart the Python-dict-literal header that follows the
+// magic and version bytes, e.g.:
+//
+//	{'descr': '<f4', 'fortran_order': False, 'shape': (2, 3), }
+var npyHeaderRe = regexp.MustCompile(`'descr':\s*'([^']+)',\s*'fortran_order':\s*(True|False),\s*'shape':\s*\(([^)]*)\),`)
+
+// npyDescrToDtype maps a NumPy array-protocol type string (the `descr`
+// field of a .npy header) to a gorgonia Dtype. Only native-endian,
+// standard-size dtypes are supported; anything else is rejected.
+var npyDescrToDtype = map[string]Dtype{
+	"<f4": Float32,
+	"<f8": Float64,
+	"<i4": Int32,
+	"<i8": Int64,
+	"<u4": Uint32,
+	"<u8": Uint64,
+	"|u1": Uint8,
+	"|i1": Int8,
+	"|b1": Bool,
+}
+
+// dtypeToNpyDescr is the inverse of npyDescrToDtype, used when writing.
+var dtypeToNpyDescr = map[Dtype]string{
+	Float32: "<f4",
+	Float64: "<f8",
+	Int32:   "<i4",
+	Int64:   "<i8",
+	Uint32:  "<u4",
+	Uint64:  "<u8",
+	Uint8:   "|u1",
+	Int8:    "|i1",
+	Bool:    "|b1",
+}
+
+// ReadNpy reads a single array encoded in NumPy's .npy format from r and
+// returns it as a *Dense. It understands both v1.0 (2-byte header length)
+// and v2.0 (4-byte header length) of the format.
+//
+// Non-native byte orders (big-endian descr strings such as ">f4") are
+// byteswapped transparently while reading; fortran-ordered (column-major)
+// arrays are transposed into row-major order before being stored in the
+// returned Dense's backing data.
+func ReadNpy(r io.Reader) (*Dense, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, 6)
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, errors.Wrap(err, "failed to read .npy magic")
+	}
+	if string(magic) != string(npyMagic) {
+		return nil, errors.Errorf("not a valid .npy file: bad magic %q", magic)
+	}
+
+	var major, minor uint8
+	if err := binary.Read(br, binary.LittleEndian, &major); err != nil {
+		return nil, errors.Wrap(err, "failed to read .npy major version")
+	}
+	if err := binary.Read(br, binary.LittleEndian, &minor); err != nil {
+		return nil, errors.Wrap(err, "failed to read .npy minor version")
+	}
+
+	var headerLen int
+	switch major {
+	case 1:
+		var hl uint16
+		if err := binary.Read(br, binary.LittleEndian, &hl); err != nil {
+			return nil, errors.Wrap(err, "failed to read v1 header length")
+		}
+		headerLen = int(hl)
+	case 2, 3:
+		var hl uint32
+		if err := binary.Read(br, binary.LittleEndian, &hl); err != nil {
+			return nil, errors.Wrap(err, "failed to read v2/v3 header length")
+		}
+		headerLen = int(hl)
+	default:
+		return nil, errors.Errorf("unsupported .npy version %d.%d", major, minor)
+	}
+
+	headerBytes := make([]byte, headerLen)
+	if _, err := io.ReadFull(br, headerBytes); err != nil {
+		return nil, errors.Wrap(err, "failed to read .npy header")
+	}
+
+	descr, fortranOrder, shape, err := parseNpyHeader(string(headerBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	dt, swap, err := descrToDtype(descr)
+	if err != nil {
+		return nil, err
+	}
+
+	size := shape.TotalSize()
+	data := make([]byte, size*int(dt.Size()))
+	if _, err := io.ReadFull(br, data); err != nil {
+		return nil, errors.Wrap(err, "failed to read .npy payload")
+	}
+	if swap {
+		byteswap(data, int(dt.Size()))
+	}
+
+	d := New(Of(dt), WithShape(shape...))
+	if err := d.hdr().fromRawBytes(data); err != nil {
+		return nil, errors.Wrap(err, "failed to populate Dense from .npy payload")
+	}
+
+	if fortranOrder {
+		d.fortranToC(shape)
+	}
+	return d, nil
+}
+
+// WriteNpy writes t to w in NumPy's .npy v1.0 format, always in native
+// byte order and row-major (C) order.
+func WriteNpy(w io.Writer, t *Dense) error {
+	descr, ok := dtypeToNpyDescr[t.Dtype()]
+	if !ok {
+		return errors.Errorf("cannot write .npy: unsupported dtype %v", t.Dtype())
+	}
+
+	shapeStr := shapeToTuple(t.Shape())
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", descr, shapeStr)
+
+	// header + magic(6) + ver(2) + headerlen(2) must be a multiple of 64,
+	// padded with spaces and terminated with '\n'.
+	const preambleLen = 6 + 2 + 2
+	total := preambleLen + len(header) + 1
+	pad := (64 - total%64) % 64
+	header = header + strings.Repeat(" ", pad) + "\n"
+
+	if _, err := w.Write(npyMagic); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte{1, 0}); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint16(len(header))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	_, err := w.Write(t.hdr().rawBytes())
+	return err
+}
+
+// ReadNpyFile opens path and reads it as a .npy file. It is a convenience
+// wrapper around ReadNpy for callers that don't otherwise need an
+// io.Reader.
+func ReadNpyFile(path string) (*Dense, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %q", path)
+	}
+	defer f.Close()
+	return ReadNpy(f)
+}
+
+// WriteNpyFile creates path and writes t to it as a .npy file. It is a
+// convenience wrapper around WriteNpy for callers that don't otherwise
+// need an io.Writer.
+func WriteNpyFile(path string, t *Dense) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %q", path)
+	}
+	defer f.Close()
+	return WriteNpy(f, t)
+}
+
+// parseNpyHeader extracts the descr, fortran_order and shape fields out of
+// a .npy header dict literal.
+func parseNpyHeader(header string) (descr string, fortranOrder bool, shape Shape, err error) {
+	m := npyHeaderRe.FindStringSubmatch(header)
+	if m == nil {
+		return "", false, nil, errors.Errorf("could not parse .npy header %q", header)
+	}
+	descr = m[1]
+	fortranOrder = m[2] == "True"
+
+	dims := strings.Split(strings.TrimSpace(m[3]), ",")
+	for _, d := range dims {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+		n, e := strconv.Atoi(d)
+		if e != nil {
+			return "", false, nil, errors.Wrapf(e, "could not parse shape dimension %q", d)
+		}
+		shape = append(shape, n)
+	}
+	if len(shape) == 0 {
+		shape = Shape{1}
+	}
+	return descr, fortranOrder, shape, nil
+}
+
+// descrToDtype maps a NumPy descr string to a Dtype, reporting whether the
+// bytes need to be swapped from the file's byte order into native order.
+func descrToDtype(descr string) (dt Dtype, swap bool, err error) {
+	if dt, ok := npyDescrToDtype[descr]; ok {
+		return dt, false, nil
+	}
+
+	// try the opposite-endian spelling and mark for a byteswap.
+	if len(descr) > 0 && (descr[0] == '<' || descr[0] == '>') {
+		flipped := "<" + descr[1:]
+		if descr[0] == '<' {
+			flipped = ">" + descr[1:]
+		}
+		if dt, ok := npyDescrToDtype[flipped]; ok {
+			return dt, true, nil
+		}
+	}
+	return Dtype{}, false, errors.Errorf("unsupported .npy descr %q", descr)
+}
+
+// fortranToC permutes d's backing data from Fortran (column-major) order
+// into C (row-major) order in place, for the given shape. It's ReadNpy's
+// counterpart to a .npy header's 'fortran_order': True flag: a Dense's
+// backing data is always expected to be laid out row-major, so a
+// column-major payload must be permuted into row-major terms before
+// anything else in the package indexes into it.
+func (d *Dense) fortranToC(shape Shape) {
+	data := reflect.ValueOf(d.Data())
+	n := data.Len()
+	if n == 0 {
+		return
+	}
+
+	ndims := len(shape)
+	// fStrides[i] is the column-major (Fortran) stride of axis i: the
+	// leading axis varies fastest, unlike row-major's trailing axis.
+	fStrides := make([]int, ndims)
+	stride := 1
+	for i := 0; i < ndims; i++ {
+		fStrides[i] = stride
+		stride *= shape[i]
+	}
+
+	out := reflect.MakeSlice(data.Type(), n, n)
+	idx := make([]int, ndims)
+	for cIdx := 0; cIdx < n; cIdx++ {
+		// unravel cIdx, a flat row-major (C) index, into per-axis indices
+		rem := cIdx
+		for i := ndims - 1; i >= 0; i-- {
+			idx[i] = rem % shape[i]
+			rem /= shape[i]
+		}
+
+		fIdx := 0
+		for i := 0; i < ndims; i++ {
+			fIdx += idx[i] * fStrides[i]
+		}
+		out.Index(cIdx).Set(data.Index(fIdx))
+	}
+
+	reflect.Copy(data, out)
+}
+
+// byteswap reverses the byte order of each elemSize-wide element of data
+// in place.
+func byteswap(data []byte, elemSize int) {
+	if elemSize <= 1 {
+		return
+	}
+	for i := 0; i < len(data); i += elemSize {
+		el := data[i : i+elemSize]
+		for l, r := 0, len(el)-1; l < r; l, r = l+1, r-1 {
+			el[l], el[r] = el[r], el[l]
+		}
+	}
+}
+
+// shapeToTuple renders a Shape as the body of a Python tuple literal, e.g.
+// Shape{2, 3} -> "2, 3" and Shape{5} -> "5,".
+func shapeToTuple(s Shape) string {
+	parts := make([]string, len(s))
+	for i, d := range s {
+		parts[i] = strconv.Itoa(d)
+	}
+	str := strings.Join(parts, ", ")
+	if len(s) == 1 {
+		str += ","
+	}
+	return str
+}
@@ -0,0 +1,156 @@
+package tensor
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNpyRoundTrip writes a Dense out via WriteNpy and reads it back via
+// ReadNpy, checking that shape and data survive the trip.
+func TestNpyRoundTrip(t *testing.T) {
+	a := New(Of(Float32), WithShape(2, 3), WithBacking([]float32{1, 2, 3, 4, 5, 6}))
+
+	var buf bytes.Buffer
+	if err := WriteNpy(&buf, a); err != nil {
+		t.Fatalf("WriteNpy failed: %v", err)
+	}
+
+	b, err := ReadNpy(&buf)
+	if err != nil {
+		t.Fatalf("ReadNpy failed: %v", err)
+	}
+
+	assert.True(t, a.Shape().Eq(b.Shape()), "shape did not round-trip: %v != %v", a.Shape(), b.Shape())
+	assert.Equal(t, a.Data(), b.Data())
+}
+
+// TestReadNpyFixture reads a .npy fixture generated by NumPy
+// (numpy.save("fixture_f4.npy", numpy.arange(6, dtype="<f4").reshape(2, 3)))
+// to guard against header-parsing regressions against the real format.
+func TestReadNpyFixture(t *testing.T) {
+	d, err := ReadNpyFile(filepath.Join("testdata", "fixture_f4.npy"))
+	if err != nil {
+		t.Fatalf("ReadNpyFile failed: %v", err)
+	}
+	assert.Equal(t, Shape{2, 3}, d.Shape())
+	assert.Equal(t, Float32, d.Dtype())
+	assert.Equal(t, []float32{0, 1, 2, 3, 4, 5}, d.Data())
+}
+
+// TestReadNpyFortranOrderFixture reads a hand-built .npy fixture with
+// 'fortran_order': True and the same (2, 3) arange(6) values as
+// fixture_f4.npy, but stored column-major, to guard fortranToC against
+// regressions: ReadNpy must transpose it back to the same row-major data
+// a C-order file of the same logical array would produce.
+func TestReadNpyFortranOrderFixture(t *testing.T) {
+	d, err := ReadNpyFile(filepath.Join("testdata", "fixture_f4_fortran.npy"))
+	if err != nil {
+		t.Fatalf("ReadNpyFile failed: %v", err)
+	}
+	assert.Equal(t, Shape{2, 3}, d.Shape())
+	assert.Equal(t, Float32, d.Dtype())
+	assert.Equal(t, []float32{0, 1, 2, 3, 4, 5}, d.Data())
+}
+
+// TestNpyFortranOrderRoundTrip writes a Dense out in C order, manually
+// rewrites the header to claim 'fortran_order': True with the payload
+// permuted to match, and checks ReadNpy transposes it back to the
+// original row-major data - i.e. the same round trip TestNpyRoundTrip
+// does, but exercising the fortran_order path end to end.
+func TestNpyFortranOrderRoundTrip(t *testing.T) {
+	a := New(Of(Float32), WithShape(2, 3), WithBacking([]float32{1, 2, 3, 4, 5, 6}))
+
+	var buf bytes.Buffer
+	if err := WriteNpy(&buf, a); err != nil {
+		t.Fatalf("WriteNpy failed: %v", err)
+	}
+	fortranBytes := toFortranOrder(t, buf.Bytes(), a.Shape())
+
+	b, err := ReadNpy(bytes.NewReader(fortranBytes))
+	if err != nil {
+		t.Fatalf("ReadNpy failed: %v", err)
+	}
+	assert.True(t, a.Shape().Eq(b.Shape()), "shape did not round-trip: %v != %v", a.Shape(), b.Shape())
+	assert.Equal(t, a.Data(), b.Data())
+}
+
+// toFortranOrder rewrites a C-order .npy payload (as produced by WriteNpy)
+// into a column-major one with its header's fortran_order flag flipped to
+// True, for TestNpyFortranOrderRoundTrip.
+func toFortranOrder(t *testing.T, npy []byte, shape Shape) []byte {
+	t.Helper()
+
+	headerLen := int(npy[8]) | int(npy[9])<<8
+	payloadOff := 10 + headerLen
+	header := string(npy[10:payloadOff])
+	fortranHeader := strings.Replace(header, "'fortran_order': False", "'fortran_order': True", 1)
+	if fortranHeader == header {
+		t.Fatalf("could not find fortran_order field in header %q", header)
+	}
+
+	payload := npy[payloadOff:]
+	const elemSize = 4 // float32
+	n := len(payload) / elemSize
+
+	cStrides := make([]int, len(shape))
+	stride := 1
+	for i := len(shape) - 1; i >= 0; i-- {
+		cStrides[i] = stride
+		stride *= shape[i]
+	}
+	fStrides := make([]int, len(shape))
+	stride = 1
+	for i := 0; i < len(shape); i++ {
+		fStrides[i] = stride
+		stride *= shape[i]
+	}
+
+	out := make([]byte, len(payload))
+	idx := make([]int, len(shape))
+	for cIdx := 0; cIdx < n; cIdx++ {
+		rem := cIdx
+		for i := 0; i < len(shape); i++ {
+			idx[i] = rem / cStrides[i]
+			rem %= cStrides[i]
+		}
+		fIdx := 0
+		for i := range shape {
+			fIdx += idx[i] * fStrides[i]
+		}
+		copy(out[fIdx*elemSize:(fIdx+1)*elemSize], payload[cIdx*elemSize:(cIdx+1)*elemSize])
+	}
+
+	var rewritten bytes.Buffer
+	rewritten.Write(npy[:10])
+	rewritten.WriteString(fortranHeader)
+	rewritten.Write(out)
+	return rewritten.Bytes()
+}
+
+// TestNpzRoundTrip writes several named tensors out via WriteNpz and reads
+// them back via ReadNpz.
+func TestNpzRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fixture.npz")
+
+	named := map[string]*Dense{
+		"a": New(Of(Float64), WithShape(2, 2), WithBacking([]float64{1, 2, 3, 4})),
+		"b": New(Of(Int32), WithShape(3), WithBacking([]int32{5, 6, 7})),
+	}
+	if err := WriteNpz(path, named); err != nil {
+		t.Fatalf("WriteNpz failed: %v", err)
+	}
+
+	got, err := ReadNpz(path)
+	if err != nil {
+		t.Fatalf("ReadNpz failed: %v", err)
+	}
+	assert.Len(t, got, 2)
+	for name, want := range named {
+		assert.True(t, want.Shape().Eq(got[name].Shape()), "shape mismatch for %q", name)
+	}
+}
@@ -0,0 +1,61 @@
+package tensor
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ReadNpz reads a NumPy .npz archive (a zip file of .npy entries, as
+// produced by numpy.savez) from path and returns the contained tensors
+// keyed by their array name.
+//
+// The ".npy" suffix that numpy.savez appends to each entry's filename is
+// stripped from the returned keys.
+func ReadNpz(path string) (map[string]*Dense, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %q as a .npz archive", path)
+	}
+	defer zr.Close()
+
+	named := make(map[string]*Dense, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open entry %q in %q", f.Name, path)
+		}
+		d, err := ReadNpy(rc)
+		rc.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read entry %q in %q", f.Name, path)
+		}
+		named[strings.TrimSuffix(f.Name, ".npy")] = d
+	}
+	return named, nil
+}
+
+// WriteNpz writes named as a NumPy .npz archive (a zip file of .npy
+// entries, readable by numpy.load) to path.
+func WriteNpz(path string, named map[string]*Dense) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %q", path)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, d := range named {
+		w, err := zw.Create(fmt.Sprintf("%s.npy", name))
+		if err != nil {
+			return errors.Wrapf(err, "failed to add entry %q to %q", name, path)
+		}
+		if err := WriteNpy(w, d); err != nil {
+			return errors.Wrapf(err, "failed to write entry %q to %q", name, path)
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,232 @@
+package tensor
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// WithStrictTypes keeps StdEng's historical behaviour of rejecting
+// operands whose Dtypes don't match exactly, instead of the default
+// NumPy-style type promotion PromoteTypes performs.
+func WithStrictTypes() FuncOpt {
+	return func(opt *OpOpt) {
+		opt.strictTypes = true
+	}
+}
+
+// promotionRank orders the numeric kinds PromoteTypes understands from
+// narrowest to widest within their signedness class; Bool always ranks
+// below every numeric kind.
+var promotionRank = map[reflect.Kind]int{
+	reflect.Bool:    0,
+	reflect.Int8:    1,
+	reflect.Uint8:   1,
+	reflect.Int16:   2,
+	reflect.Uint16:  2,
+	reflect.Int32:   3,
+	reflect.Uint32:  3,
+	reflect.Int:     4,
+	reflect.Int64:   4,
+	reflect.Uint:    4,
+	reflect.Uint64:  4,
+	reflect.Float32: 5,
+	reflect.Float64: 6,
+}
+
+// signedOfRank maps a promotionRank back to the signed integer Dtype of
+// that width, used when promoting a mismatched signed/unsigned pair to
+// "the next-larger signed type". Rank 4 maps to Int64 itself: it's only
+// reached when the unsigned operand is narrower than rank 4 (e.g. Uint32
+// paired with Int64), in which case Int64 already fits the unsigned
+// operand's full range - see PromoteTypes for the rank-4-unsigned case,
+// which bypasses this map and promotes to Float64 instead.
+var signedOfRank = map[int]Dtype{
+	1: Int16,
+	2: Int32,
+	3: Int64,
+	4: Int64,
+}
+
+func isFloatKind(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+func isUnsignedKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// PromoteTypes computes the NumPy-style common Dtype for a binary op
+// between operands of Dtype a and b:
+//
+//   - Bool always promotes to whatever the other operand is.
+//   - if either side is a float, the result is the wider of the two
+//     floats (or the float side, if only one operand is a float).
+//   - same-signedness integers promote to the wider of the two.
+//   - a signed/unsigned integer pair promotes to the next-larger signed
+//     type, so the unsigned operand's range still fits without
+//     wrapping - except at 64-bit width, where there is no larger
+//     signed integer type: a Uint64/Uint paired with Int64/Int instead
+//     promotes to Float64, matching NumPy, since Int64 cannot represent
+//     every Uint64 value without wrapping.
+func PromoteTypes(a, b Dtype) (Dtype, error) {
+	ak, bk := a.Kind(), b.Kind()
+	if ak == bk {
+		return a, nil
+	}
+
+	ar, aok := promotionRank[ak]
+	br, bok := promotionRank[bk]
+	if !aok || !bok {
+		return Dtype{}, errors.Errorf(typeMismatch, a, b)
+	}
+
+	switch {
+	case ak == reflect.Bool:
+		return b, nil
+	case bk == reflect.Bool:
+		return a, nil
+	case isFloatKind(ak) && isFloatKind(bk):
+		if ar > br {
+			return a, nil
+		}
+		return b, nil
+	case isFloatKind(ak):
+		return a, nil
+	case isFloatKind(bk):
+		return b, nil
+	case isUnsignedKind(ak) == isUnsignedKind(bk):
+		if ar >= br {
+			return a, nil
+		}
+		return b, nil
+	default:
+		rank := ar
+		if br > rank {
+			rank = br
+		}
+		// Float64 is only required when the unsigned operand itself is at
+		// rank 4 (Uint64/Uint): its range can exceed what any signed
+		// integer type can represent. A narrower unsigned operand (e.g.
+		// Uint32) paired with a rank-4 signed operand (Int64) fits in
+		// Int64 just fine and should promote there instead.
+		unsignedRank := ar
+		if isUnsignedKind(bk) {
+			unsignedRank = br
+		}
+		if unsignedRank >= 4 {
+			return Float64, nil
+		}
+		return signedOfRank[rank], nil
+	}
+}
+
+// convertElement converts v (of some numeric or Bool Kind) to target's
+// Kind, taking the widest reasonable representation rather than
+// truncating through an intermediate integer - e.g. Float32->Float64
+// keeps the exact value instead of round-tripping through int64.
+func convertElement(v reflect.Value, target reflect.Type) reflect.Value {
+	nv := reflect.New(target).Elem()
+	switch target.Kind() {
+	case reflect.Float32, reflect.Float64:
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			nv.SetFloat(v.Float())
+		case reflect.Bool:
+			if v.Bool() {
+				nv.SetFloat(1)
+			}
+		default:
+			if isUnsignedKind(v.Kind()) {
+				nv.SetFloat(float64(v.Uint()))
+			} else {
+				nv.SetFloat(float64(v.Int()))
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			nv.SetInt(int64(v.Float()))
+		case reflect.Bool:
+			if v.Bool() {
+				nv.SetInt(1)
+			}
+		default:
+			if isUnsignedKind(v.Kind()) {
+				nv.SetInt(int64(v.Uint()))
+			} else {
+				nv.SetInt(v.Int())
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			nv.SetUint(uint64(v.Float()))
+		case reflect.Bool:
+			if v.Bool() {
+				nv.SetUint(1)
+			}
+		default:
+			if isUnsignedKind(v.Kind()) {
+				nv.SetUint(v.Uint())
+			} else {
+				nv.SetUint(uint64(v.Int()))
+			}
+		}
+	case reflect.Bool:
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			nv.SetBool(v.Float() != 0)
+		case reflect.Bool:
+			nv.SetBool(v.Bool())
+		default:
+			if isUnsignedKind(v.Kind()) {
+				nv.SetBool(v.Uint() != 0)
+			} else {
+				nv.SetBool(v.Int() != 0)
+			}
+		}
+	}
+	return nv
+}
+
+// castDense returns a new *Dense holding d's data converted element-wise
+// to dt, leaving d untouched. If d is already of dt's Kind, d is
+// returned as-is.
+func castDense(d *Dense, dt Dtype) (*Dense, error) {
+	if d.Dtype().Kind() == dt.Kind() {
+		return d, nil
+	}
+
+	src := reflect.ValueOf(d.Data())
+	n := src.Len()
+	dst := reflect.MakeSlice(reflect.SliceOf(dt.Type), n, n)
+	for i := 0; i < n; i++ {
+		dst.Index(i).Set(convertElement(src.Index(i), dt.Type))
+	}
+
+	return New(Of(dt), WithShape(d.Shape()...), WithBacking(dst.Interface())), nil
+}
+
+// promoteOperand converts t to dt when necessary, dispatching on
+// whether t is a DenseTensor or a *CS so StdEng.Add's promotion path
+// works for both the dense-dense and sparse-involving cases.
+func promoteOperand(t Tensor, dt Dtype) (Tensor, error) {
+	if t.Dtype().Kind() == dt.Kind() {
+		return t, nil
+	}
+
+	switch tt := t.(type) {
+	case *Dense:
+		return castDense(tt, dt)
+	case *CS:
+		return tt.AsType(dt)
+	default:
+		return nil, errors.Errorf(typeNYI, "promoteOperand", t)
+	}
+}
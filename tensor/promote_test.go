@@ -0,0 +1,57 @@
+package tensor
+
+import "testing"
+
+// TestPromoteTypesUint64DoesNotWrap guards against the bug this test
+// accompanies: promoting Uint64 against Int64 used to land on Int64,
+// and a value above math.MaxInt64 would silently wrap to a negative
+// number once cast. Promoting to Float64 instead must preserve its
+// (large, positive) magnitude.
+func TestPromoteTypesUint64DoesNotWrap(t *testing.T) {
+	const huge = uint64(1) << 63 // math.MaxInt64 + 1; wraps to negative as an int64
+
+	promoted, err := PromoteTypes(Uint64, Int64)
+	if err != nil {
+		t.Fatalf("PromoteTypes(Uint64, Int64) returned error: %v", err)
+	}
+	if promoted.Kind().String() != "float64" {
+		t.Fatalf("PromoteTypes(Uint64, Int64) = %v, want float64", promoted)
+	}
+
+	d := New(Of(Uint64), WithShape(1), WithBacking([]uint64{huge}))
+	cast, err := castDense(d, promoted)
+	if err != nil {
+		t.Fatalf("castDense returned error: %v", err)
+	}
+	got := cast.Data().([]float64)[0]
+	if got < 0 {
+		t.Fatalf("castDense(%v, float64) = %v, want a non-negative value near %v", huge, got, huge)
+	}
+}
+
+func TestPromoteTypes(t *testing.T) {
+	tests := []struct {
+		a, b Dtype
+		want Dtype
+	}{
+		{Int32, Float32, Float32},
+		{Float32, Float64, Float64},
+		{Bool, Int32, Int32},
+		{Uint8, Int8, Int16},
+		{Int32, Int32, Int32},
+		{Uint64, Int64, Float64},
+		{Uint, Int, Float64},
+		{Uint32, Int64, Int64},
+	}
+
+	for _, tc := range tests {
+		got, err := PromoteTypes(tc.a, tc.b)
+		if err != nil {
+			t.Errorf("PromoteTypes(%v, %v) returned error: %v", tc.a, tc.b, err)
+			continue
+		}
+		if got.Kind() != tc.want.Kind() {
+			t.Errorf("PromoteTypes(%v, %v) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
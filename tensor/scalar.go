@@ -0,0 +1,380 @@
+package tensor
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// scalarOp bundles the four StdEng entry points (plain, iterator, incr,
+// iterator+incr) that a scalar-tensor arithmetic function dispatches to.
+// It lets AddScalar/SubScalar/etc share the prep/dispatch plumbing that
+// Add hand-rolls, since unlike Add they don't need per-operation type
+// switches on a second Tensor operand.
+type scalarOp struct {
+	name       string
+	fn         func(typ reflect.Type, a, b *header) error
+	fnIter     func(typ reflect.Type, a, b *header, ait, bit Iterator) error
+	fnIncr     func(typ reflect.Type, a, b, incr *header) error
+	fnIterIncr func(typ reflect.Type, a, b, incr *header, ait, bit, iit Iterator) error
+}
+
+// stdEngScalar implements `a OP b` (or `b OP a` when !leftTensor) where a
+// is a Tensor and b is a Go scalar. b is converted to a's Dtype (via
+// convertScalar) and turned into a stride-0 header by scalarToHeader, so
+// the scalar is "broadcast" across a without ever being materialized as
+// a full tensor.
+func (e StdEng) stdEngScalar(op scalarOp, a Tensor, b interface{}, leftTensor bool, opts ...FuncOpt) (retVal Tensor, err error) {
+	var reuse *Dense
+	var safe, toReuse, incr bool
+	if reuse, safe, toReuse, incr, err = prepUnaryTensor(a, opts...); err != nil {
+		return
+	}
+
+	if reuse != nil && !reuse.IsNativelyAccessible() {
+		err = errors.Errorf(inaccessibleData, reuse)
+		return
+	}
+
+	converted, err := convertScalar(b, a.Dtype())
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot use %v as a scalar operand of %s", b, op.name)
+	}
+	scalar := scalarToHeader(converted)
+
+	var dataA, dataReuse *header
+	var ait, iit Iterator
+	var useIter bool
+	typ := a.Dtype().Type
+
+	switch at := a.(type) {
+	case DenseTensor:
+		dataA = at.hdr()
+		if requiresIterator(at) {
+			ait = IteratorFromDense(at)
+			useIter = true
+		}
+		if reuse != nil {
+			dataReuse = reuse.hdr()
+			if useIter {
+				iit = IteratorFromDense(reuse)
+			}
+		}
+	default:
+		err = errors.Errorf(typeNYI, "StdEng."+op.name, a)
+		return
+	}
+
+	// operands, left-to-right as passed to the engine: the scalar side is
+	// fixed, but which side the tensor's data occupies depends on which
+	// header holds the data the op should actually mutate (dataA for an
+	// unsafe/overwrite op, dataReuse once primed via copyHeader, or a
+	// fresh clone's header for a safe allocation).
+	sides := func(tensorSide *header) (l, r *header) {
+		if leftTensor {
+			return tensorSide, scalar
+		}
+		return scalar, tensorSide
+	}
+
+	if useIter {
+		switch {
+		case incr:
+			l, r := sides(dataA)
+			err = op.fnIterIncr(typ, l, r, dataReuse, ait, nil, iit)
+			retVal = reuse
+		case toReuse:
+			copyHeader(dataReuse, dataA, typ)
+			l, r := sides(dataReuse)
+			err = op.fnIter(typ, l, r, ait, nil)
+			retVal = reuse
+		case !safe:
+			l, r := sides(dataA)
+			err = op.fnIter(typ, l, r, ait, nil)
+			retVal = a
+		default:
+			ret := a.Clone().(headerer)
+			l, r := sides(ret.hdr())
+			err = op.fnIter(typ, l, r, ait, nil)
+			retVal = ret.(Tensor)
+		}
+		return
+	}
+
+	switch {
+	case incr:
+		l, r := sides(dataA)
+		err = op.fnIncr(typ, l, r, dataReuse)
+		retVal = reuse
+	case toReuse:
+		copyHeader(dataReuse, dataA, typ)
+		l, r := sides(dataReuse)
+		err = op.fn(typ, l, r)
+		retVal = reuse
+	case !safe:
+		l, r := sides(dataA)
+		err = op.fn(typ, l, r)
+		retVal = a
+	default:
+		ret := a.Clone().(headerer)
+		l, r := sides(ret.hdr())
+		err = op.fn(typ, l, r)
+		retVal = ret.(Tensor)
+	}
+	return
+}
+
+// AddScalar performs a + b (or b + a when !leftTensor) where b is a Go
+// scalar, converted to a.Dtype(). It's the scalar-tensor counterpart to
+// StdEng.Add: the scalar is passed through as a stride-0 header so no
+// intermediate tensor is allocated for it.
+func (e StdEng) AddScalar(a Tensor, b interface{}, leftTensor bool, opts ...FuncOpt) (retVal Tensor, err error) {
+	if cs, ok := a.(*CS); ok {
+		return e.addScalarCS(cs, b, leftTensor, opts...)
+	}
+	return e.stdEngScalar(scalarOp{
+		name:       "AddScalar",
+		fn:         e.E.Add,
+		fnIter:     e.E.AddIter,
+		fnIncr:     e.E.AddIncr,
+		fnIterIncr: e.E.AddIterIncr,
+	}, a, b, leftTensor, opts...)
+}
+
+// SubScalar performs a - b (or b - a when !leftTensor) where b is a Go
+// scalar, converted to a.Dtype().
+func (e StdEng) SubScalar(a Tensor, b interface{}, leftTensor bool, opts ...FuncOpt) (retVal Tensor, err error) {
+	return e.stdEngScalar(scalarOp{
+		name:       "SubScalar",
+		fn:         e.E.Sub,
+		fnIter:     e.E.SubIter,
+		fnIncr:     e.E.SubIncr,
+		fnIterIncr: e.E.SubIterIncr,
+	}, a, b, leftTensor, opts...)
+}
+
+// MulScalar performs a * b (or b * a when !leftTensor) where b is a Go
+// scalar, converted to a.Dtype().
+func (e StdEng) MulScalar(a Tensor, b interface{}, leftTensor bool, opts ...FuncOpt) (retVal Tensor, err error) {
+	if cs, ok := a.(*CS); ok {
+		return e.mulScalarCS(cs, b, leftTensor, opts...)
+	}
+	return e.stdEngScalar(scalarOp{
+		name:       "MulScalar",
+		fn:         e.E.Mul,
+		fnIter:     e.E.MulIter,
+		fnIncr:     e.E.MulIncr,
+		fnIterIncr: e.E.MulIterIncr,
+	}, a, b, leftTensor, opts...)
+}
+
+// DivScalar performs a / b (or b / a when !leftTensor) where b is a Go
+// scalar, converted to a.Dtype().
+func (e StdEng) DivScalar(a Tensor, b interface{}, leftTensor bool, opts ...FuncOpt) (retVal Tensor, err error) {
+	return e.stdEngScalar(scalarOp{
+		name:       "DivScalar",
+		fn:         e.E.Div,
+		fnIter:     e.E.DivIter,
+		fnIncr:     e.E.DivIncr,
+		fnIterIncr: e.E.DivIterIncr,
+	}, a, b, leftTensor, opts...)
+}
+
+// PowScalar performs a ^ b (or b ^ a when !leftTensor) where b is a Go
+// scalar, converted to a.Dtype().
+func (e StdEng) PowScalar(a Tensor, b interface{}, leftTensor bool, opts ...FuncOpt) (retVal Tensor, err error) {
+	return e.stdEngScalar(scalarOp{
+		name:       "PowScalar",
+		fn:         e.E.Pow,
+		fnIter:     e.E.PowIter,
+		fnIncr:     e.E.PowIncr,
+		fnIterIncr: e.E.PowIterIncr,
+	}, a, b, leftTensor, opts...)
+}
+
+// ModScalar performs a % b (or b % a when !leftTensor) where b is a Go
+// scalar, converted to a.Dtype().
+func (e StdEng) ModScalar(a Tensor, b interface{}, leftTensor bool, opts ...FuncOpt) (retVal Tensor, err error) {
+	return e.stdEngScalar(scalarOp{
+		name:       "ModScalar",
+		fn:         e.E.Mod,
+		fnIter:     e.E.ModIter,
+		fnIncr:     e.E.ModIncr,
+		fnIterIncr: e.E.ModIterIncr,
+	}, a, b, leftTensor, opts...)
+}
+
+// addScalarCS performs the sparse-tensor path of AddScalar: add b to
+// every structural nonzero of cs, returning a new *CS of the same
+// sparsity pattern. Add is commutative, so leftTensor only affects
+// non-commutative error reporting, never the result.
+func (e StdEng) addScalarCS(cs *CS, b interface{}, leftTensor bool, opts ...FuncOpt) (retVal Tensor, err error) {
+	converted, err := convertScalar(b, cs.Dtype())
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot use %v as a scalar operand of AddScalar", b)
+	}
+	scalar := scalarToHeader(converted)
+	typ := cs.Dtype().Type
+
+	ret := cs.Clone().(*CS)
+	err = e.E.Add(typ, ret.hdr(), scalar)
+	return ret, err
+}
+
+// mulScalarCS performs the sparse-tensor path of MulScalar: multiply
+// every structural nonzero of cs by b, returning a new *CS of the same
+// sparsity pattern. Mul is commutative, so leftTensor only affects
+// non-commutative error reporting, never the result.
+func (e StdEng) mulScalarCS(cs *CS, b interface{}, leftTensor bool, opts ...FuncOpt) (retVal Tensor, err error) {
+	converted, err := convertScalar(b, cs.Dtype())
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot use %v as a scalar operand of MulScalar", b)
+	}
+	scalar := scalarToHeader(converted)
+	typ := cs.Dtype().Type
+
+	ret := cs.Clone().(*CS)
+	err = e.E.Mul(typ, ret.hdr(), scalar)
+	return ret, err
+}
+
+// convertScalar converts a Go numeric scalar to dt's native Go type,
+// returning a typeMismatch error if the conversion would lose
+// information (e.g. a fractional float64 into an integer Dtype, or a
+// value that overflows a narrower integer Dtype).
+func convertScalar(b interface{}, dt Dtype) (interface{}, error) {
+	rv := reflect.ValueOf(b)
+	if !rv.IsValid() {
+		return nil, errors.Errorf(typeMismatch, dt, "<nil>")
+	}
+
+	target := dt.Type
+	if rv.Type() == target {
+		return b, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return convertToDtype(rv.Int(), target, dt)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return convertToDtype(int64(rv.Uint()), target, dt)
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if isFloatKind(dt.Kind()) {
+			// float->float never loses information in the sense this
+			// function guards against (narrowing float64->float32 is
+			// the caller's own choice of dt, not a surprise); converting
+			// through int64 first would truncate the fractional part.
+			nv := reflect.New(target).Elem()
+			nv.SetFloat(f)
+			return nv.Interface(), nil
+		}
+		if f != float64(int64(f)) {
+			return nil, errors.Errorf(typeMismatch, dt, rv.Type())
+		}
+		return convertToDtype(int64(f), target, dt)
+	default:
+		return nil, errors.Errorf(typeMismatch, dt, rv.Type())
+	}
+}
+
+// convertToDtype reflects an int64 value into target's concrete Go
+// numeric type, erroring if it would overflow.
+func convertToDtype(v int64, target reflect.Type, dt Dtype) (interface{}, error) {
+	nv := reflect.New(target).Elem()
+	switch target.Kind() {
+	case reflect.Float32, reflect.Float64:
+		nv.SetFloat(float64(v))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if nv.OverflowInt(v) {
+			return nil, errors.Errorf(typeMismatch, dt, v)
+		}
+		nv.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v < 0 || nv.OverflowUint(uint64(v)) {
+			return nil, errors.Errorf(typeMismatch, dt, v)
+		}
+		nv.SetUint(uint64(v))
+	default:
+		return nil, errors.Errorf(typeMismatch, dt, target)
+	}
+	return nv.Interface(), nil
+}
+
+// AddScalar performs t + x (or x + t when !leftTensor), with x converted
+// to t.Dtype(). It is the Tensor-interface convenience wrapper around
+// StdEng.AddScalar.
+func (t *Dense) AddScalar(x interface{}, leftTensor bool, opts ...FuncOpt) (Tensor, error) {
+	e, ok := t.Engine().(interface {
+		AddScalar(a Tensor, b interface{}, leftTensor bool, opts ...FuncOpt) (Tensor, error)
+	})
+	if !ok {
+		return nil, errors.Errorf("engine %T does not support AddScalar", t.Engine())
+	}
+	return e.AddScalar(t, x, leftTensor, opts...)
+}
+
+// SubScalar performs t - x (or x - t when !leftTensor), with x converted
+// to t.Dtype(). It is the Tensor-interface convenience wrapper around
+// StdEng.SubScalar.
+func (t *Dense) SubScalar(x interface{}, leftTensor bool, opts ...FuncOpt) (Tensor, error) {
+	e, ok := t.Engine().(interface {
+		SubScalar(a Tensor, b interface{}, leftTensor bool, opts ...FuncOpt) (Tensor, error)
+	})
+	if !ok {
+		return nil, errors.Errorf("engine %T does not support SubScalar", t.Engine())
+	}
+	return e.SubScalar(t, x, leftTensor, opts...)
+}
+
+// MulScalar performs t * x (or x * t when !leftTensor), with x converted
+// to t.Dtype(). It is the Tensor-interface convenience wrapper around
+// StdEng.MulScalar.
+func (t *Dense) MulScalar(x interface{}, leftTensor bool, opts ...FuncOpt) (Tensor, error) {
+	e, ok := t.Engine().(interface {
+		MulScalar(a Tensor, b interface{}, leftTensor bool, opts ...FuncOpt) (Tensor, error)
+	})
+	if !ok {
+		return nil, errors.Errorf("engine %T does not support MulScalar", t.Engine())
+	}
+	return e.MulScalar(t, x, leftTensor, opts...)
+}
+
+// DivScalar performs t / x (or x / t when !leftTensor), with x converted
+// to t.Dtype(). It is the Tensor-interface convenience wrapper around
+// StdEng.DivScalar.
+func (t *Dense) DivScalar(x interface{}, leftTensor bool, opts ...FuncOpt) (Tensor, error) {
+	e, ok := t.Engine().(interface {
+		DivScalar(a Tensor, b interface{}, leftTensor bool, opts ...FuncOpt) (Tensor, error)
+	})
+	if !ok {
+		return nil, errors.Errorf("engine %T does not support DivScalar", t.Engine())
+	}
+	return e.DivScalar(t, x, leftTensor, opts...)
+}
+
+// PowScalar performs t ^ x (or x ^ t when !leftTensor), with x converted
+// to t.Dtype(). It is the Tensor-interface convenience wrapper around
+// StdEng.PowScalar.
+func (t *Dense) PowScalar(x interface{}, leftTensor bool, opts ...FuncOpt) (Tensor, error) {
+	e, ok := t.Engine().(interface {
+		PowScalar(a Tensor, b interface{}, leftTensor bool, opts ...FuncOpt) (Tensor, error)
+	})
+	if !ok {
+		return nil, errors.Errorf("engine %T does not support PowScalar", t.Engine())
+	}
+	return e.PowScalar(t, x, leftTensor, opts...)
+}
+
+// ModScalar performs t % x (or x % t when !leftTensor), with x converted
+// to t.Dtype(). It is the Tensor-interface convenience wrapper around
+// StdEng.ModScalar.
+func (t *Dense) ModScalar(x interface{}, leftTensor bool, opts ...FuncOpt) (Tensor, error) {
+	e, ok := t.Engine().(interface {
+		ModScalar(a Tensor, b interface{}, leftTensor bool, opts ...FuncOpt) (Tensor, error)
+	})
+	if !ok {
+		return nil, errors.Errorf("engine %T does not support ModScalar", t.Engine())
+	}
+	return e.ModScalar(t, x, leftTensor, opts...)
+}
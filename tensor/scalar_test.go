@@ -0,0 +1,105 @@
+package tensor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertScalarIntToFloat(t *testing.T) {
+	got, err := convertScalar(2, Float64)
+	if err != nil {
+		t.Fatalf("convertScalar returned error: %v", err)
+	}
+	assert.Equal(t, float64(2), got)
+}
+
+// TestConvertScalarFloatToFloatPreservesFraction guards against the bug
+// this test accompanies: a fractional float scalar used to get truncated
+// to an int64 on its way to a float Dtype, silently dropping the
+// fractional part instead of being set directly.
+func TestConvertScalarFloatToFloatPreservesFraction(t *testing.T) {
+	got, err := convertScalar(2.7, Float32)
+	if err != nil {
+		t.Fatalf("convertScalar returned error: %v", err)
+	}
+	assert.Equal(t, float32(2.7), got)
+}
+
+func TestConvertScalarFractionalIntoIntErrors(t *testing.T) {
+	_, err := convertScalar(2.5, Int32)
+	assert.Error(t, err, "a fractional float scalar into an integer Dtype should error")
+}
+
+func TestConvertScalarOverflowErrors(t *testing.T) {
+	_, err := convertScalar(300, Int8)
+	assert.Error(t, err, "a scalar that overflows the target integer Dtype should error")
+}
+
+// TestAddScalarFloatFraction is AddScalar's end-to-end counterpart to
+// TestConvertScalarFloatToFloatPreservesFraction: adding a fractional
+// float64 scalar to a Float32 tensor must add the full value, not just
+// its integer part.
+func TestAddScalarFloatFraction(t *testing.T) {
+	a := New(Of(Float32), WithShape(2), WithBacking([]float32{1, 10}))
+
+	got, err := StdEng{}.AddScalar(a, 2.7, true)
+	if err != nil {
+		t.Fatalf("AddScalar returned error: %v", err)
+	}
+	assert.Equal(t, []float32{3.7, 12.7}, got.(*Dense).Data())
+}
+
+func TestSubScalar(t *testing.T) {
+	a := New(Of(Float64), WithShape(2), WithBacking([]float64{5, 10}))
+
+	got, err := StdEng{}.SubScalar(a, 3.0, true)
+	if err != nil {
+		t.Fatalf("SubScalar returned error: %v", err)
+	}
+	assert.Equal(t, []float64{2, 7}, got.(*Dense).Data())
+}
+
+// TestSubScalarRightOperand checks the !leftTensor case: b - a, not a - b.
+func TestSubScalarRightOperand(t *testing.T) {
+	a := New(Of(Float64), WithShape(2), WithBacking([]float64{5, 10}))
+
+	got, err := StdEng{}.SubScalar(a, 20.0, false)
+	if err != nil {
+		t.Fatalf("SubScalar returned error: %v", err)
+	}
+	assert.Equal(t, []float64{15, 10}, got.(*Dense).Data())
+}
+
+func TestMulScalar(t *testing.T) {
+	a := New(Of(Float64), WithShape(2), WithBacking([]float64{2, 3}))
+
+	got, err := StdEng{}.MulScalar(a, 4.0, true)
+	if err != nil {
+		t.Fatalf("MulScalar returned error: %v", err)
+	}
+	assert.Equal(t, []float64{8, 12}, got.(*Dense).Data())
+}
+
+func TestDivScalar(t *testing.T) {
+	a := New(Of(Float64), WithShape(2), WithBacking([]float64{8, 9}))
+
+	got, err := StdEng{}.DivScalar(a, 2.0, true)
+	if err != nil {
+		t.Fatalf("DivScalar returned error: %v", err)
+	}
+	assert.Equal(t, []float64{4, 4.5}, got.(*Dense).Data())
+}
+
+// TestScalarUnsafe checks the unsafe path still computes the right
+// values (see Add's doc comment for the unsafe/reuse/safe contract,
+// which the scalar ops share via stdEngScalar).
+func TestScalarUnsafe(t *testing.T) {
+	a := New(Of(Float64), WithShape(2), WithBacking([]float64{1, 2}))
+
+	got, err := StdEng{}.AddScalar(a, 1.0, true, UseUnsafe())
+	if err != nil {
+		t.Fatalf("AddScalar returned error: %v", err)
+	}
+	assert.Equal(t, []float64{2, 3}, got.(*Dense).Data())
+}